@@ -0,0 +1,147 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Services --alias-service knows the alias hosted zone IDs of
+const (
+	aliasCloudFront = "cloudfront"
+	aliasS3Website  = "s3-website"
+	aliasELB        = "elb"
+	aliasAPIGateway = "apigateway"
+)
+
+var aliasServices = []string{aliasCloudFront, aliasS3Website, aliasELB, aliasAPIGateway}
+
+// cloudFrontZoneID is the hosted zone of every CloudFront distribution,
+// and so of edge-optimized API Gateway domains
+const cloudFrontZoneID = "Z2FDTNDATAQYW2"
+
+// Alias hosted zone IDs per region, from the AWS general reference. ELB
+// covers Application and Classic Load Balancers; Network Load Balancers
+// have their own.
+var (
+	s3WebsiteZoneIDs = map[string]string{
+		"us-east-1": "Z3AQBSTGFYJSTF", "us-east-2": "Z2O1EMRO9K5GLX",
+		"us-west-1": "Z2F56UZL2M1ACD", "us-west-2": "Z3BJ6K6RIION7M",
+		"ca-central-1": "Z1QDHH18159H29", "sa-east-1": "Z7KQH4QJS55SO",
+		"eu-central-1": "Z21DNDUVLTQW6Q", "eu-west-1": "Z1BKCTXD74EZPE",
+		"eu-west-2": "Z3GKZC51ZF0DB4", "eu-west-3": "Z3R1K369G5AVDG",
+		"eu-north-1": "Z3BAZG2TWCNX0D", "ap-south-1": "Z11RGJOFQNVJUP",
+		"ap-northeast-1": "Z2M4EHUR26P7ZW", "ap-northeast-2": "Z3W03O7B5YMIYP",
+		"ap-northeast-3": "Z2YQB5RD63NC85", "ap-southeast-1": "Z3O0J2DXBE1FTB",
+		"ap-southeast-2": "Z1WCIGYICN2BYD",
+	}
+	elbZoneIDs = map[string]string{
+		"us-east-1": "Z35SXDOTRQ7X7K", "us-east-2": "Z3AADJGX6KTTL2",
+		"us-west-1": "Z368ELLRRE2KJ0", "us-west-2": "Z1H1FL5HABSF5",
+		"ca-central-1": "ZQSVJUPU6J1EY", "sa-east-1": "Z2P70J7HTTTPLU",
+		"eu-central-1": "Z215JYRZR1TBD5", "eu-west-1": "Z32O12XQLNTSW2",
+		"eu-west-2": "ZHURV8PSTC4K8", "eu-west-3": "Z3Q77PNBQS71R4",
+		"eu-north-1": "Z23TAZ7KFP0ZS8", "ap-south-1": "ZP97RAFLXTNZK",
+		"ap-northeast-1": "Z14GRHDCWA56QT", "ap-northeast-2": "ZWKZPGTI48KDX",
+		"ap-northeast-3": "Z5LXEXXYW11ES", "ap-southeast-1": "Z1LMS91P8CMLE5",
+		"ap-southeast-2": "Z1GM3OXH4ZPM65",
+	}
+	nlbZoneIDs = map[string]string{
+		"us-east-1": "Z26RNL4JYFTOTI", "us-east-2": "ZLMOA37VPKANP",
+		"us-west-1": "Z24FKFUX50B4VW", "us-west-2": "Z18D5FSROUN65G",
+		"ca-central-1": "Z2EPGBW3API2WT", "sa-east-1": "ZTK26PT1VY4CU",
+		"eu-central-1": "Z3F0SRJ5LGBH90", "eu-west-1": "Z2IFOLAFXWLO4F",
+		"eu-west-2": "ZD4D7Y8KGAS4G", "eu-west-3": "Z1CMS0P5QUZ6D5",
+		"eu-north-1": "Z1UDT6IFJ4EJM", "ap-south-1": "ZVDDRBQ08TROA",
+		"ap-northeast-1": "Z31USIVHYNEOWT", "ap-northeast-2": "ZIBE1TIR4HY56",
+		"ap-northeast-3": "Z1GWIQ4HH19I5X", "ap-southeast-1": "ZKVM4W9LS7TM",
+		"ap-southeast-2": "ZCT6FZBF4DROD",
+	}
+	apiGatewayZoneIDs = map[string]string{
+		"us-east-1": "Z1UJRXOUMOOFQ8", "us-east-2": "ZOJJZC49E0EPZ",
+		"us-west-1": "Z2MUQ32089INYE", "us-west-2": "Z2OJLYMUO9EFXC",
+		"ca-central-1": "Z19DQILCV0OWEC", "sa-east-1": "ZCMLWB8V5SYIT",
+		"eu-central-1": "Z1U9ULNL0V5AJ3", "eu-west-1": "ZLY8HYME6SFDD",
+		"eu-west-2": "ZJ5UAJN8Y3Z2Q", "eu-west-3": "Z3KY65QIEKYHQQ",
+		"eu-north-1": "Z3UWIKFBOOGXPP", "ap-south-1": "Z3VO1THU9YC4UR",
+		"ap-northeast-1": "Z1YSHQZHG15GKL", "ap-northeast-2": "Z20JF4UZKIW1U8",
+		"ap-southeast-1": "ZL327KTPIQFUL", "ap-southeast-2": "Z2RPCDW04V8134",
+	}
+)
+
+// Target hostnames of each service, capturing the region they are in
+var (
+	s3WebsiteHost  = regexp.MustCompile(`(?:^|\.)s3-website[.-]([a-z0-9-]+)\.amazonaws\.com$`)
+	elbHost        = regexp.MustCompile(`\.([a-z0-9-]+)\.elb\.amazonaws\.com$`)
+	nlbHost        = regexp.MustCompile(`\.elb\.([a-z0-9-]+)\.amazonaws\.com$`)
+	apiGatewayHost = regexp.MustCompile(`\.execute-api\.([a-z0-9-]+)\.amazonaws\.com$`)
+)
+
+// aliasZoneID returns the alias hosted zone ID of target, a hostname of
+// service, in the region the hostname names
+func aliasZoneID(service, target string) (string, error) {
+	host := strings.ToLower(strings.TrimSuffix(target, "."))
+	var ids map[string]string
+	var match []string
+	switch service {
+	case aliasCloudFront:
+		if !strings.HasSuffix(host, ".cloudfront.net") {
+			return "", invalid(fmt.Errorf("%s is not a CloudFront distribution (*.cloudfront.net)", target))
+		}
+		return cloudFrontZoneID, nil
+	case aliasS3Website:
+		ids, match = s3WebsiteZoneIDs, s3WebsiteHost.FindStringSubmatch(host)
+		if match == nil {
+			return "", invalid(fmt.Errorf("%s is not an S3 website endpoint (*.s3-website-<region>.amazonaws.com)", target))
+		}
+	case aliasELB:
+		ids, match = elbZoneIDs, elbHost.FindStringSubmatch(host)
+		if match == nil {
+			ids, match = nlbZoneIDs, nlbHost.FindStringSubmatch(host)
+		}
+		if match == nil {
+			return "", invalid(fmt.Errorf("%s is not a load balancer (*.elb.amazonaws.com)", target))
+		}
+	case aliasAPIGateway:
+		// edge-optimized custom domains are served by CloudFront
+		if strings.HasSuffix(host, ".cloudfront.net") {
+			return cloudFrontZoneID, nil
+		}
+		ids, match = apiGatewayZoneIDs, apiGatewayHost.FindStringSubmatch(host)
+		if match == nil {
+			return "", invalid(fmt.Errorf("%s is not an API Gateway domain (*.execute-api.<region>.amazonaws.com or *.cloudfront.net)", target))
+		}
+	default:
+		return "", invalid(fmt.Errorf("unknown --alias-service %q (want one of %s)", service, strings.Join(aliasServices, ", ")))
+	}
+	id, ok := ids[match[1]]
+	if !ok {
+		return "", invalid(fmt.Errorf("no known %s alias zone in region %s; pass --alias-hosted-zone-id", service, match[1]))
+	}
+	return id, nil
+}
+
+// checkAlias validates the alias flags of spec and fills in its
+// aliasZoneID from aliasService
+func checkAlias(spec *recordSpec) error {
+	switch {
+	case spec.aliasTarget == "":
+		if spec.aliasService != "" || spec.aliasZoneID != "" {
+			return invalid(errors.New("--alias-service and --alias-hosted-zone-id need --alias-target"))
+		}
+		return nil
+	case spec.aliasService != "" && spec.aliasZoneID != "":
+		return invalid(errors.New("--alias-service and --alias-hosted-zone-id cannot be combined"))
+	case spec.aliasService == "" && spec.aliasZoneID == "":
+		return invalid(fmt.Errorf("--alias-target needs --alias-hosted-zone-id or --alias-service (%s)", strings.Join(aliasServices, ", ")))
+	case spec.aliasZoneID != "":
+		return nil
+	}
+	id, err := aliasZoneID(spec.aliasService, spec.aliasTarget)
+	if err != nil {
+		return err
+	}
+	spec.aliasZoneID = id
+	return nil
+}
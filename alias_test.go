@@ -0,0 +1,39 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAliasZoneID(t *testing.T) {
+	tests := []struct {
+		service, target, want, wantErr string
+	}{
+		{"cloudfront", "d111111abcdef8.cloudfront.net.", "Z2FDTNDATAQYW2", ""},
+		{"cloudfront", "lb-1.eu-west-1.elb.amazonaws.com", "", "not a CloudFront distribution"},
+		{"s3-website", "s3-website-us-east-1.amazonaws.com", "Z3AQBSTGFYJSTF", ""},
+		{"s3-website", "www.ear.pm.s3-website.eu-central-1.amazonaws.com", "Z21DNDUVLTQW6Q", ""},
+		{"s3-website", "www.ear.pm.s3.amazonaws.com", "", "not an S3 website endpoint"},
+		{"elb", "dualstack.lb-123.eu-west-1.elb.amazonaws.com", "Z32O12XQLNTSW2", ""},
+		{"elb", "nlb-abc.elb.eu-west-1.amazonaws.com", "Z2IFOLAFXWLO4F", ""},
+		{"elb", "lb-123.xx-nowhere-1.elb.amazonaws.com", "", "no known elb alias zone in region xx-nowhere-1"},
+		{"apigateway", "d-abc123.execute-api.us-west-2.amazonaws.com", "Z2OJLYMUO9EFXC", ""},
+		{"apigateway", "d3abc.cloudfront.net", "Z2FDTNDATAQYW2", ""},
+		{"apigateway", "api.ear.pm", "", "not an API Gateway domain"},
+		{"beanstalk", "env.eu-west-1.elasticbeanstalk.com", "", "unknown --alias-service"},
+	}
+	for _, tt := range tests {
+		got, err := aliasZoneID(tt.service, tt.target)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("aliasZoneID(%s, %s): err = %v, want %q", tt.service, tt.target, err, tt.wantErr)
+			} else if exitCode(err) != exitInvalid {
+				t.Errorf("aliasZoneID(%s, %s): exit code %d, want %d", tt.service, tt.target, exitCode(err), exitInvalid)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("aliasZoneID(%s, %s) = %s, %v, want %s", tt.service, tt.target, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+// planChanges turns the differences found by diffRecordSets into Route53
+// changes: CREATE for additions, UPSERT for modifications and, with prune,
+// DELETE for sets missing from the file. Deletions go first so a name can
+// change type, and aliases are deleted before and created after plain sets
+// so their targets exist throughout. Also returns how many removals were
+// skipped for lack of prune.
+func planChanges(diff []setChange, prune bool) ([]*route53.Change, int) {
+	var delAlias, delPlain, plain, alias []*route53.Change
+	var kept int
+	for _, c := range diff {
+		switch {
+		case c.want == nil && !prune:
+			kept++
+		case c.want == nil:
+			ch := &route53.Change{Action: aws.String(route53.ChangeActionDelete), ResourceRecordSet: c.live}
+			if c.live.AliasTarget != nil {
+				delAlias = append(delAlias, ch)
+			} else {
+				delPlain = append(delPlain, ch)
+			}
+		default:
+			action := route53.ChangeActionUpsert
+			if c.live == nil {
+				action = route53.ChangeActionCreate
+			}
+			ch := &route53.Change{Action: aws.String(action), ResourceRecordSet: c.want}
+			if c.want.AliasTarget != nil {
+				alias = append(alias, ch)
+			} else {
+				plain = append(plain, ch)
+			}
+		}
+	}
+	var changes []*route53.Change
+	for _, group := range [][]*route53.Change{delAlias, delPlain, plain, alias} {
+		changes = append(changes, group...)
+	}
+	return changes, kept
+}
+
+// precheckChanges checks changes as a whole before any is sent: the values
+// of every set created or updated, each change against the per-request
+// limits, and the zone that applying them to live would leave behind (no
+// CNAME at the apex or beside other types). Every problem is reported, not
+// just the first.
+func precheckChanges(changes []*route53.Change, live []*route53.ResourceRecordSet, zoneName string) error {
+	var problems []string
+	result := map[string]*route53.ResourceRecordSet{}
+	for _, rr := range live {
+		result[setKey(rr)] = rr
+	}
+	for _, c := range changes {
+		rr := c.ResourceRecordSet
+		action, typ := aws.StringValue(c.Action), aws.StringValue(rr.Type)
+		label := fmt.Sprintf("%s %s %s", action, aws.StringValue(rr.Name), typ)
+		if n, size := changeWeight(c); n > maxBatchRecords || size > maxBatchChars {
+			problems = append(problems, fmt.Sprintf("%s: %d records and %d characters exceed one request's limits", label, n, size))
+		}
+		if action == route53.ChangeActionDelete {
+			delete(result, setKey(rr))
+			continue
+		}
+		result[setKey(rr)] = rr
+		if rr.AliasTarget != nil {
+			continue
+		}
+		if rr.TTL == nil || len(rr.ResourceRecords) == 0 {
+			problems = append(problems, label+": needs a TTL and values")
+			continue
+		}
+		values := make([]string, len(rr.ResourceRecords))
+		for i, r := range rr.ResourceRecords {
+			values[i] = aws.StringValue(r.Value)
+		}
+		if _, err := validateValues(typ, values); err != nil {
+			problems = append(problems, label+": "+err.Error())
+		}
+	}
+
+	types := map[string][]string{}
+	for _, rr := range result {
+		name := strings.ToLower(strings.TrimSuffix(aws.StringValue(rr.Name), "."))
+		if t := aws.StringValue(rr.Type); !slices.Contains(types[name], t) {
+			types[name] = append(types[name], t)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(types)) {
+		ts := types[name]
+		switch {
+		case !slices.Contains(ts, route53.RRTypeCname):
+		case equalNames(name, zoneName):
+			problems = append(problems, name+": a CNAME cannot be at the zone apex")
+		case len(ts) > 1:
+			slices.Sort(ts)
+			problems = append(problems, fmt.Sprintf("%s: a CNAME cannot coexist with other types (%s)", name, strings.Join(ts, ", ")))
+		}
+	}
+	if len(problems) > 0 {
+		return invalid(fmt.Errorf("pre-check found %d problems, nothing was sent:\n  %s", len(problems), strings.Join(problems, "\n  ")))
+	}
+	return nil
+}
+
+// applyZone makes the live zone (by ID or domain) match the record sets in
+// file, in as few ChangeResourceRecordSets calls as the batch limits allow,
+// and prints the change IDs. Live sets missing from the file are deleted
+// only with prune, after confirmation unless assumeYes. The apex NS/SOA
+// are left alone unless includeManaged, and so are alias and routing-policy
+// sets when file is a zone file. With atomic the whole change set is
+// checked first (see precheckChanges) and nothing is sent if any of it
+// would fail, since a failing later batch cannot undo earlier ones.
+func applyZone(ctx context.Context, svc Route53API, identifier, file, comment string, prune, includeManaged, atomic, assumeYes bool) error {
+	zone, _, err := findZone(ctx, svc, identifier)
+	if err != nil {
+		return err
+	}
+	zoneName := aws.StringValue(zone.Name)
+	live, want, err := loadDesiredLive(ctx, svc, zone, file)
+	if err != nil {
+		return err
+	}
+
+	changes, kept := planChanges(diffRecordSets(live, want, zoneName, includeManaged), prune)
+	if kept > 0 && !quiet {
+		fmt.Fprintf(os.Stderr, "keeping %d record sets not in %s (use --prune to delete them)\n", kept, file)
+	}
+	if len(changes) == 0 {
+		if !quiet {
+			fmt.Fprintf(os.Stderr, "%s already matches %s\n", strings.TrimSuffix(zoneName, "."), file)
+		}
+		return nil
+	}
+
+	if atomic {
+		if err := precheckChanges(changes, live, zoneName); err != nil {
+			return err
+		}
+		if n := len(batchChanges(changes)); n > 1 && !quiet {
+			fmt.Fprintf(os.Stderr, "pre-check passed; the changes need %d requests, each applied atomically on its own\n", n)
+		}
+	}
+
+	counts := map[string]int{}
+	for _, c := range changes {
+		counts[aws.StringValue(c.Action)]++
+	}
+	summary := fmt.Sprintf("%d to create, %d to update, %d to delete", counts[route53.ChangeActionCreate],
+		counts[route53.ChangeActionUpsert], counts[route53.ChangeActionDelete])
+	if counts[route53.ChangeActionDelete] > 0 && !assumeYes &&
+		!confirm(fmt.Sprintf("Apply to %s: %s?", strings.TrimSuffix(zoneName, "."), summary)) {
+		return errors.New("aborted")
+	}
+	if !quiet {
+		fmt.Fprintln(os.Stderr, summary)
+	}
+
+	ids, err := submitChanges(ctx, svc, aws.StringValue(zone.Id), changes, comment)
+	for _, id := range ids {
+		fmt.Println(id)
+	}
+	if err != nil {
+		return err
+	}
+	return waitForChanges(ctx, svc, ids...)
+}
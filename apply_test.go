@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+func aliasSet(name, typ, target string) *route53.ResourceRecordSet {
+	return &route53.ResourceRecordSet{Name: aws.String(name), Type: aws.String(typ),
+		AliasTarget: &route53.AliasTarget{DNSName: aws.String(target), HostedZoneId: aws.String("Z2FDTNDATAQYW2"),
+			EvaluateTargetHealth: aws.Bool(false)}}
+}
+
+// changeList renders changes as "ACTION name type" for comparison
+func changeList(changes []*route53.Change) []string {
+	out := make([]string, len(changes))
+	for i, c := range changes {
+		out[i] = aws.StringValue(c.Action) + " " + aws.StringValue(c.ResourceRecordSet.Name) + " " +
+			aws.StringValue(c.ResourceRecordSet.Type)
+	}
+	return out
+}
+
+func TestPlanChanges(t *testing.T) {
+	a1 := plainSet("a.ear.pm.", "A", 60, "1.1.1.1")
+	a2 := plainSet("a.ear.pm.", "A", 60, "2.2.2.2")
+	b := plainSet("b.ear.pm.", "A", 60, "3.3.3.3")
+	c := plainSet("c.ear.pm.", "TXT", 60, `"c"`)
+	cdnOld := aliasSet("cdn.ear.pm.", "A", "old.cloudfront.net.")
+	cdnNew := aliasSet("cdn2.ear.pm.", "A", "new.cloudfront.net.")
+	diff := []setChange{
+		{live: nil, want: cdnNew}, // alias create
+		{live: a1, want: a2},      // upsert
+		{live: b, want: nil},      // removal
+		{live: nil, want: c},      // create
+		{live: cdnOld, want: nil}, // alias removal
+	}
+	tests := []struct {
+		name  string
+		prune bool
+		want  []string
+		kept  int
+	}{
+		{"without prune", false, []string{
+			"UPSERT a.ear.pm. A",
+			"CREATE c.ear.pm. TXT",
+			"CREATE cdn2.ear.pm. A",
+		}, 2},
+		{"with prune deletes first, aliases around plain sets", true, []string{
+			"DELETE cdn.ear.pm. A",
+			"DELETE b.ear.pm. A",
+			"UPSERT a.ear.pm. A",
+			"CREATE c.ear.pm. TXT",
+			"CREATE cdn2.ear.pm. A",
+		}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, kept := planChanges(diff, tt.prune)
+			if got := changeList(changes); !slices.Equal(got, tt.want) {
+				t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+			if kept != tt.kept {
+				t.Errorf("kept %d, want %d", kept, tt.kept)
+			}
+		})
+	}
+	// deletions repeat the live set, creations and upserts send the wanted one
+	changes, _ := planChanges(diff, true)
+	if changes[1].ResourceRecordSet != b || changes[2].ResourceRecordSet != a2 {
+		t.Error("changes carry the wrong side of the diff")
+	}
+}
+
+// applyMock returns a mock whose zone ear.pm holds the Route53-managed apex
+// NS/SOA, a plain A, an alias and a weighted set
+func applyMock() *mockRoute53 {
+	m := newMock()
+	weighted := plainSet("api.ear.pm.", "A", 60, "10.0.0.1")
+	weighted.SetIdentifier, weighted.Weight = aws.String("blue"), aws.Int64(10)
+	m.sets["/hostedzone/Z1"] = []*route53.ResourceRecordSet{
+		plainSet("ear.pm.", "NS", 172800, "ns-1.awsdns-01.org."),
+		plainSet("ear.pm.", "SOA", 900, "ns-1.awsdns-01.org. awsdns-hostmaster.amazon.com. 1 7200 900 1209600 86400"),
+		plainSet("ear.pm.", "A", 300, "1.2.3.4"),
+		aliasSet("cdn.ear.pm.", "A", "d1.cloudfront.net."),
+		weighted,
+	}
+	return m
+}
+
+func TestApplyZone(t *testing.T) {
+	const json = `[{"name": "ear.pm.", "type": "A", "ttl": 300, "values": ["1.2.3.5"]},
+		{"name": "new", "type": "TXT", "ttl": 60, "values": ["hi"]}]`
+	const zone = "@ 300 IN A 1.2.3.5\nnew 60 IN TXT \"hi\"\n"
+	tests := []struct {
+		name           string
+		file, content  string
+		prune, managed bool
+		want           []string
+	}{
+		{"json without prune", "want.json", json, false, false, []string{
+			"UPSERT ear.pm. A",
+			"CREATE new.ear.pm. TXT",
+		}},
+		{"json with prune", "want.json", json, true, false, []string{
+			"DELETE cdn.ear.pm. A",
+			"DELETE api.ear.pm. A",
+			"UPSERT ear.pm. A",
+			"CREATE new.ear.pm. TXT",
+		}},
+		{"json with prune and include-managed", "want.json", json, true, true, []string{
+			"DELETE cdn.ear.pm. A",
+			"DELETE api.ear.pm. A",
+			"DELETE ear.pm. NS",
+			"DELETE ear.pm. SOA",
+			"UPSERT ear.pm. A",
+			"CREATE new.ear.pm. TXT",
+		}},
+		// a zone file cannot hold the alias or the weighted set, so
+		// pruning must leave them alone
+		{"zone file with prune", "want.zone", zone, true, false, []string{
+			"UPSERT ear.pm. A",
+			"CREATE new.ear.pm. TXT",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTest(t)
+			quiet = true
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+			m := applyMock()
+			if _, err := captureStdout(t, func() error {
+				return applyZone(context.Background(), m, "ear.pm", path, "test", tt.prune, tt.managed, false, true)
+			}); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, in := range m.changes {
+				got = append(got, changeList(in.ChangeBatch.Changes)...)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestLoadDesiredDuplicate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "want.json")
+	content := `[{"name": "www", "type": "A", "ttl": 60, "values": ["1.1.1.1"]},
+		{"name": "WWW.ear.pm.", "type": "a", "ttl": 60, "values": ["2.2.2.2"]}]`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err := loadDesired(path, "ear.pm.")
+	if err == nil || !strings.Contains(err.Error(), "record 2 (WWW.ear.pm. a) repeats record 1") {
+		t.Fatalf("err = %v, want a repeat error", err)
+	}
+	if exitCode(err) != exitInvalid {
+		t.Errorf("exit code %d, want %d", exitCode(err), exitInvalid)
+	}
+}
+
+func TestPrecheckChanges(t *testing.T) {
+	change := func(action string, rr *route53.ResourceRecordSet) *route53.Change {
+		return &route53.Change{Action: aws.String(action), ResourceRecordSet: rr}
+	}
+	var many []string
+	for i := 0; i < 501; i++ {
+		many = append(many, fmt.Sprintf("10.0.%d.%d", i/256, i%256))
+	}
+	live := []*route53.ResourceRecordSet{
+		plainSet("ear.pm.", "A", 300, "1.2.3.4"),
+		plainSet("www.ear.pm.", "CNAME", 60, "ear.pm."),
+		plainSet("api.ear.pm.", "A", 60, "10.0.0.1"),
+	}
+	tests := []struct {
+		name    string
+		changes []*route53.Change
+		want    []string // problems, "" for none
+	}{
+		{"valid", []*route53.Change{change("CREATE", plainSet("new.ear.pm.", "TXT", 60, `"hi"`))}, nil},
+		{"CNAME replaced by A", []*route53.Change{
+			change("DELETE", live[1]),
+			change("CREATE", plainSet("www.ear.pm.", "A", 60, "1.2.3.4")),
+		}, nil},
+		{"bad value", []*route53.Change{change("UPSERT", plainSet("api.ear.pm.", "A", 60, "::1"))},
+			[]string{`UPSERT api.ear.pm. A: invalid A value "::1": not an IPv4 address`}},
+		{"CNAME beside A", []*route53.Change{change("CREATE", plainSet("api.ear.pm.", "CNAME", 60, "ear.pm."))},
+			[]string{"api.ear.pm: a CNAME cannot coexist with other types (A, CNAME)"}},
+		{"CNAME at the apex", []*route53.Change{change("DELETE", live[0]), change("CREATE", plainSet("ear.pm.", "CNAME", 60, "example.com."))},
+			[]string{"ear.pm: a CNAME cannot be at the zone apex"}},
+		{"too large, and every problem reported", []*route53.Change{
+			change("UPSERT", plainSet("api.ear.pm.", "A", 60, many...)),
+			change("CREATE", plainSet("bad.ear.pm.", "MX", 60, "mx.ear.pm.")),
+		}, []string{
+			"UPSERT api.ear.pm. A: 1002 records and",
+			"CREATE bad.ear.pm. MX: invalid MX value",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := precheckChanges(tt.changes, live, "ear.pm.")
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("no error")
+			}
+			if exitCode(err) != exitInvalid {
+				t.Errorf("exit code %d, want %d", exitCode(err), exitInvalid)
+			}
+			if want := fmt.Sprintf("found %d problems", len(tt.want)); !strings.Contains(err.Error(), want) {
+				t.Errorf("err %q lacks %q", err, want)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(err.Error(), w) {
+					t.Errorf("err %q lacks %q", err, w)
+				}
+			}
+		})
+	}
+}
+
+// --atomic sends nothing when any change would fail; without it the valid
+// changes go out
+func TestApplyZoneAtomic(t *testing.T) {
+	for _, atomic := range []bool{true, false} {
+		setupTest(t)
+		quiet = true
+		path := filepath.Join(t.TempDir(), "want.zone")
+		if err := os.WriteFile(path, []byte("@ 300 IN A 1.2.3.5\napi 60 IN CNAME ear.pm.\napi 60 IN TXT \"x\"\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		m := applyMock()
+		_, err := captureStdout(t, func() error {
+			return applyZone(context.Background(), m, "ear.pm", path, "test", false, false, atomic, true)
+		})
+		if atomic {
+			if err == nil || !strings.Contains(err.Error(), "a CNAME cannot coexist") {
+				t.Errorf("err = %v, want a CNAME problem", err)
+			}
+			if len(m.changes) != 0 {
+				t.Errorf("sent %d batches despite the failed pre-check", len(m.changes))
+			}
+		} else if err != nil || len(m.changes) != 1 {
+			t.Errorf("without --atomic: err = %v, %d batches", err, len(m.changes))
+		}
+	}
+}
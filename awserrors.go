@@ -0,0 +1,34 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+)
+
+// deniedAction pulls "route53:ListHostedZones" out of IAM denial messages
+// such as "... is not authorized to perform: route53:ListHostedZones on ..."
+var deniedAction = regexp.MustCompile(`perform: ([A-Za-z0-9-]+:[A-Za-z0-9]+)`)
+
+// friendlyError turns IAM permission failures and exhausted throttling
+// retries into a one-line hint and passes every other error through
+// untouched
+func friendlyError(err error) error {
+	var aerr awserr.Error
+	if !errors.As(err, &aerr) {
+		return err
+	}
+	switch aerr.Code() {
+	case "AccessDenied", "AccessDeniedException", "UnauthorizedOperation":
+		if m := deniedAction.FindStringSubmatch(aerr.Message()); m != nil {
+			return fmt.Errorf("permission denied: your IAM identity lacks %s", m[1])
+		}
+		return errors.New("permission denied: your IAM identity lacks the required Route53 permission")
+	case "Throttling", "ThrottlingException", "PriorRequestNotComplete":
+		return fmt.Errorf("rate limited by Route53 even after %d retries; wait a moment, or raise --max-retries (%s)",
+			maxRetries, aerr.Message())
+	}
+	return err
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+// defaultZoneTTL is the $TTL written when a zone has no SOA to take it from
+const defaultZoneTTL = 300
+
+// writeBindZone emits sets as an RFC 1035 master file for origin. Route53
+// already stores values in presentation format, so they are written as-is
+// apart from quoting bare TXT strings, turning TXT byte escapes decimal
+// and spreading the SOA over lines.
+// Alias and routing-policy sets have no zone-file equivalent and are
+// written as comments.
+func writeBindZone(w io.Writer, origin, zoneID string, sets []*route53.ResourceRecordSet) error {
+	if err := writeBindHeader(w, origin, zoneID, zoneTTL(sets, origin)); err != nil {
+		return err
+	}
+	return writeBindSets(w, origin, sets)
+}
+
+// zoneTTL returns the TTL of the apex SOA among sets, else defaultZoneTTL
+func zoneTTL(sets []*route53.ResourceRecordSet, origin string) int64 {
+	for _, rr := range sets {
+		if aws.StringValue(rr.Type) == route53.RRTypeSoa && equalNames(aws.StringValue(rr.Name), origin) {
+			return aws.Int64Value(rr.TTL)
+		}
+	}
+	return defaultZoneTTL
+}
+
+// writeBindHeader starts a zone file with a comment, $ORIGIN and $TTL
+func writeBindHeader(w io.Writer, origin, zoneID string, ttl int64) error {
+	_, err := fmt.Fprintf(w, "; zone %s (%s) exported by r53q\n$ORIGIN %s\n$TTL %d\n",
+		strings.TrimSuffix(origin, "."), zoneID, origin, ttl)
+	return err
+}
+
+// writeBindSets writes the records of sets as writeBindZone does, without
+// the header, so a zone can be written page by page
+func writeBindSets(w io.Writer, origin string, sets []*route53.ResourceRecordSet) error {
+	for _, rr := range sets {
+		name := bindName(unescapeName(aws.StringValue(rr.Name)), origin)
+		typ := aws.StringValue(rr.Type)
+		var lines []string
+		switch {
+		case rr.AliasTarget != nil:
+			lines = []string{fmt.Sprintf("; %s IN %s ALIAS %s (zone %s)", name, typ,
+				aws.StringValue(rr.AliasTarget.DNSName), aws.StringValue(rr.AliasTarget.HostedZoneId))}
+		default:
+			for _, r := range rr.ResourceRecords {
+				lines = append(lines, fmt.Sprintf("%s\t%d\tIN\t%s\t%s", name, aws.Int64Value(rr.TTL), typ,
+					bindValue(typ, aws.StringValue(r.Value))))
+			}
+		}
+		if aws.StringValue(rr.SetIdentifier) != "" {
+			// several sets share this name; a zone file would merge them
+			for i, l := range lines {
+				if !strings.HasPrefix(l, ";") {
+					lines[i] = "; " + l
+				}
+			}
+			lines = append([]string{"; " + explainRecord(rr)}, lines...)
+		}
+		for _, l := range lines {
+			if _, err := fmt.Fprintln(w, l); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// bindName writes name relative to origin, "@" for the apex
+func bindName(name, origin string) string {
+	if equalNames(name, origin) {
+		return "@"
+	}
+	if rel, ok := strings.CutSuffix(name, "."+origin); ok {
+		return rel
+	}
+	return name
+}
+
+// qualifyTarget adds the trailing dot to the hostname of a CNAME, MX, NS,
+// SRV or PTR value. Route53 treats such hostnames as absolute either way,
+// but a zone file would read them relative to $ORIGIN.
+func qualifyTarget(typ, value string) string {
+	if !hostnameTypes[typ] && typ != route53.RRTypeNs {
+		return value
+	}
+	if f := strings.Fields(value); len(f) > 0 && !strings.HasSuffix(f[len(f)-1], ".") {
+		f[len(f)-1] += "."
+		return strings.Join(f, " ")
+	}
+	return value
+}
+
+// bindValue formats one value of a record of type typ for a zone file.
+// Route53 writes the bytes of TXT strings it cannot print as octal \ooo
+// escapes, which a zone file reads as decimal \DDD.
+func bindValue(typ, value string) string {
+	value = qualifyTarget(typ, value)
+	switch typ {
+	case route53.RRTypeTxt, route53.RRTypeSpf:
+		if !strings.HasPrefix(value, `"`) {
+			value = strings.ReplaceAll(value, `\`, `\\`)
+			return `"` + strings.ReplaceAll(value, `"`, `\"`) + `"`
+		}
+		return convertEscapes(value, 8, 10)
+	case route53.RRTypeSoa:
+		// mname rname serial refresh retry expire minimum
+		if f := strings.Fields(value); len(f) == 7 {
+			return fmt.Sprintf("%s %s (\n\t\t\t\t%s\t; serial\n\t\t\t\t%s\t; refresh\n\t\t\t\t%s\t; retry\n\t\t\t\t%s\t; expire\n\t\t\t\t%s )\t; minimum",
+				f[0], f[1], f[2], f[3], f[4], f[5], f[6])
+		}
+	}
+	return value
+}
+
+// convertEscapes rewrites the three-digit byte escapes in value from base
+// from to base to: 8 for Route53's \ooo, 10 for a zone file's \DDD. Other
+// escapes such as \" and \\ are kept as they are.
+func convertEscapes(value string, from, to int) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' || i+1 == len(value) {
+			b.WriteByte(value[i])
+			continue
+		}
+		if i+3 < len(value) {
+			if n, err := strconv.ParseUint(value[i+1:i+4], from, 8); err == nil {
+				if to == 8 {
+					fmt.Fprintf(&b, "\\%03o", n)
+				} else {
+					fmt.Fprintf(&b, "\\%03d", n)
+				}
+				i += 3
+				continue
+			}
+		}
+		b.WriteString(value[i : i+2])
+		i++
+	}
+	return b.String()
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+func TestBindValue(t *testing.T) {
+	tests := []struct {
+		typ, value, want string
+	}{
+		// Route53 escapes é (0xC3 0xA9) in octal; BIND reads decimal
+		{"TXT", `"caf\303\251"`, `"caf\195\169"`},
+		{"TXT", `"back\\slash \"quoted\""`, `"back\\slash \"quoted\""`},
+		{"TXT", `"a" "b\011c"`, `"a" "b\009c"`},
+		{"SPF", `"v=spf1 \055all"`, `"v=spf1 \045all"`},
+		{"TXT", `back\slash "x"`, `"back\\slash \"x\""`},
+		{"CNAME", "ear.pm", "ear.pm."},
+		{"MX", "10 mx.ear.pm", "10 mx.ear.pm."},
+		{"SRV", "0 5 443 sip.ear.pm.", "0 5 443 sip.ear.pm."},
+		{"A", "1.2.3.4", "1.2.3.4"},
+	}
+	for _, tt := range tests {
+		if got := bindValue(tt.typ, tt.value); got != tt.want {
+			t.Errorf("bindValue(%s, %s) = %s, want %s", tt.typ, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestConvertEscapes(t *testing.T) {
+	tests := []struct {
+		in       string
+		from, to int
+		want     string
+	}{
+		{`caf\303\251`, 8, 10, `caf\195\169`},
+		{`caf\195\169`, 10, 8, `caf\303\251`},
+		{`\377`, 8, 10, `\255`},
+		{`\255`, 10, 8, `\377`},
+		// not a byte in the source base: kept as is
+		{`\400`, 8, 10, `\400`},
+		{`\256`, 10, 8, `\256`},
+		{`\19`, 10, 8, `\19`},
+		{`a\\b\"c\`, 8, 10, `a\\b\"c\`},
+	}
+	for _, tt := range tests {
+		if got := convertEscapes(tt.in, tt.from, tt.to); got != tt.want {
+			t.Errorf("convertEscapes(%s, %d, %d) = %s, want %s", tt.in, tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestWriteBindZone(t *testing.T) {
+	sets := []*route53.ResourceRecordSet{
+		plainSet("ear.pm.", "SOA", 900, "ns-1.awsdns-01.org. awsdns-hostmaster.amazon.com. 1 7200 900 1209600 86400"),
+		plainSet("ear.pm.", "TXT", 300, `"caf\303\251"`, `"C:\\temp"`),
+		plainSet("www.ear.pm.", "CNAME", 60, "ear.pm"),
+		{Name: aws.String("cdn.ear.pm."), Type: aws.String("A"),
+			AliasTarget: &route53.AliasTarget{DNSName: aws.String("d1.cloudfront.net."), HostedZoneId: aws.String("Z2FDTNDATAQYW2")}},
+	}
+	var b strings.Builder
+	if err := writeBindZone(&b, "ear.pm.", "Z1", sets); err != nil {
+		t.Fatal(err)
+	}
+	got := b.String()
+	for _, want := range []string{
+		"$ORIGIN ear.pm.\n$TTL 900\n",
+		"@\t300\tIN\tTXT\t\"caf\\195\\169\"\n",
+		"@\t300\tIN\tTXT\t\"C:\\\\temp\"\n",
+		"www\t60\tIN\tCNAME\tear.pm.\n",
+		"; cdn IN A ALIAS d1.cloudfront.net. (zone Z2FDTNDATAQYW2)\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("export lacks %q:\n%s", want, got)
+		}
+	}
+}
@@ -16,4 +16,4 @@ go build -ldflags "\
   -X 'main.appVersion=${APP_VERSION}' \
   -X 'main.gitCommit=${GIT_COMMIT}' \
   -X 'main.buildDate=${BUILD_DATE}'" \
-  -o r53q main.go
+  -o r53q .
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// cacheDirPath returns the cache location without touching the filesystem.
+// Precedence: --cache-dir, $XDG_CACHE_HOME/r53q, $HOME/.cache/r53q.
+func cacheDirPath() (string, error) {
+	if cacheDirFlag != "" {
+		return cacheDirFlag, nil
+	}
+	// the XDG spec says relative values must be ignored
+	if xdg := os.Getenv("XDG_CACHE_HOME"); xdg != "" && filepath.IsAbs(xdg) {
+		return filepath.Join(xdg, "r53q"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".cache", "r53q"), nil
+}
+
+// cacheDir returns the cache location, creating it (0700) if needed
+func cacheDir() (string, error) {
+	dir, err := cacheDirPath()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// clearCache deletes the files r53q keeps in the cache directory (the
+// zone caches, and zones.json from older versions) and returns the
+// directory and how many files went. Anything else in it is left alone, and
+// the directory itself goes only if that leaves it empty.
+func clearCache() (string, int, error) {
+	dir, err := cacheDirPath()
+	if err != nil {
+		return "", 0, err
+	}
+	files, err := filepath.Glob(filepath.Join(dir, zoneCachePattern))
+	if err != nil {
+		return dir, 0, err
+	}
+	files = append(files, filepath.Join(dir, "zones.json"))
+	var n int
+	for _, f := range files {
+		switch err := os.Remove(f); {
+		case err == nil:
+			n++
+		case !errors.Is(err, fs.ErrNotExist):
+			return dir, n, err
+		}
+	}
+	// fails, harmlessly, when the directory holds anything else
+	os.Remove(dir)
+	return dir, n, nil
+}
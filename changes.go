@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/user"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/spf13/cobra"
+)
+
+// Route53 per-request limits for ChangeResourceRecordSets. UPSERTs count
+// twice towards both.
+const (
+	maxBatchRecords = 1000
+	maxBatchChars   = 32000
+	maxCommentLen   = 256
+)
+
+// maxValueLen is the longest single record value Route53 accepts
+const maxValueLen = 4000
+
+// changeComment returns the ChangeBatch comment for a mutating command:
+// --comment if given, else "r53q <command> by <user>", naming the local
+// user and, with --assume-role-arn, the role. Built locally so mutations
+// cost no extra STS call. Truncated to Route53's limit.
+func changeComment(cmd *cobra.Command) string {
+	c := changeCommentFlag
+	if c == "" {
+		c = cmd.CommandPath()
+		if who := localUser(); who != "" {
+			c += " by " + who
+		}
+		if assumeRoleARN != "" {
+			c += " as " + assumeRoleARN
+		}
+	}
+	if r := []rune(c); len(r) > maxCommentLen {
+		c = string(r[:maxCommentLen])
+	}
+	return c
+}
+
+// localUser returns the name of the user running r53q, or "" if unknown
+func localUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// batchChanges splits changes into requests that respect Route53's
+// per-request record and character limits, keeping the original order
+func batchChanges(changes []*route53.Change) [][]*route53.Change {
+	var batches [][]*route53.Change
+	var cur []*route53.Change
+	var records, chars int
+	for _, c := range changes {
+		n, size := changeWeight(c)
+		if len(cur) > 0 && (records+n > maxBatchRecords || chars+size > maxBatchChars) {
+			batches = append(batches, cur)
+			cur, records, chars = nil, 0, 0
+		}
+		cur = append(cur, c)
+		records += n
+		chars += size
+	}
+	if len(cur) > 0 {
+		batches = append(batches, cur)
+	}
+	return batches
+}
+
+// changeWeight returns how many records and value characters a change
+// counts for against the batch limits
+func changeWeight(c *route53.Change) (int, int) {
+	rr := c.ResourceRecordSet
+	n := len(rr.ResourceRecords)
+	if n == 0 {
+		n = 1 // alias
+	}
+	var size int
+	for _, r := range rr.ResourceRecords {
+		size += len(aws.StringValue(r.Value))
+	}
+	if aws.StringValue(c.Action) == route53.ChangeActionUpsert {
+		n, size = n*2, size*2
+	}
+	return n, size
+}
+
+// submitChanges applies changes to a zone in as many batches as needed, each
+// carrying comment, and returns the change IDs, one per batch
+func submitChanges(ctx context.Context, svc Route53API, zoneID string, changes []*route53.Change, comment string) ([]string, error) {
+	var ids []string
+	for _, batch := range batchChanges(changes) {
+		out, err := svc.ChangeResourceRecordSetsWithContext(ctx, &route53.ChangeResourceRecordSetsInput{
+			HostedZoneId: aws.String(zoneID),
+			ChangeBatch:  &route53.ChangeBatch{Changes: batch, Comment: aws.String(comment)},
+		})
+		if err != nil {
+			return ids, err
+		}
+		ids = append(ids, aws.StringValue(out.ChangeInfo.Id))
+	}
+	return ids, nil
+}
+
+// zoneRecordSets fetches every record set of a zone
+func zoneRecordSets(ctx context.Context, svc Route53API, zoneID string) ([]*route53.ResourceRecordSet, error) {
+	var sets []*route53.ResourceRecordSet
+	err := svc.ListResourceRecordSetsPagesWithContext(ctx, &route53.ListResourceRecordSetsInput{
+		HostedZoneId: aws.String(zoneID),
+		MaxItems:     maxItems(0),
+	}, func(out *route53.ListResourceRecordSetsOutput, last bool) bool {
+		sets = append(sets, out.ResourceRecordSets...)
+		return !last
+	})
+	return sets, err
+}
+
+// isApexNSOrSOA reports whether rr is one of the NS/SOA sets Route53 creates
+// and manages at the zone apex
+func isApexNSOrSOA(rr *route53.ResourceRecordSet, zoneName string) bool {
+	t := aws.StringValue(rr.Type)
+	return (t == route53.RRTypeNs || t == route53.RRTypeSoa) &&
+		equalNames(aws.StringValue(rr.Name), zoneName)
+}
+
+// lookupRecordSets returns the record sets named name of type typ (all set
+// identifiers), starting the listing at that name so only a page or two is
+// fetched
+func lookupRecordSets(ctx context.Context, svc Route53API, zoneID, name, typ string) ([]*route53.ResourceRecordSet, error) {
+	var found []*route53.ResourceRecordSet
+	err := svc.ListResourceRecordSetsPagesWithContext(ctx, &route53.ListResourceRecordSetsInput{
+		HostedZoneId:    aws.String(zoneID),
+		StartRecordName: aws.String(name),
+		StartRecordType: aws.String(typ),
+	}, func(out *route53.ListResourceRecordSetsOutput, last bool) bool {
+		for _, rr := range out.ResourceRecordSets {
+			if !equalNames(unescapeName(aws.StringValue(rr.Name)), name) || aws.StringValue(rr.Type) != typ {
+				// listing is sorted, so we are past the wanted sets
+				return false
+			}
+			found = append(found, rr)
+		}
+		return !last
+	})
+	return found, err
+}
+
+// unescapeName undoes Route53's octal escaping of "*" in returned names
+func unescapeName(name string) string {
+	return strings.ReplaceAll(name, `\052`, "*")
+}
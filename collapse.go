@@ -0,0 +1,49 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+// collapseApex folds record sets at <label>.<zone> into the apex row when
+// they are identical to the apex set of the same type (same TTL, values and
+// alias target). Only simple record sets are considered. Returns the kept
+// sets and, per kept apex set, the labels folded into it.
+func collapseApex(sets []*route53.ResourceRecordSet, zoneName string, labels []string) ([]*route53.ResourceRecordSet, map[*route53.ResourceRecordSet][]string) {
+	zoneName = strings.ToLower(zoneName)
+	apex := map[string]*route53.ResourceRecordSet{}
+	for _, rr := range sets {
+		if strings.ToLower(aws.StringValue(rr.Name)) == zoneName && aws.StringValue(rr.SetIdentifier) == "" {
+			apex[aws.StringValue(rr.Type)] = rr
+		}
+	}
+
+	byName := map[string]string{}
+	for _, l := range labels {
+		byName[strings.ToLower(l)+"."+zoneName] = l
+	}
+
+	notes := map[*route53.ResourceRecordSet][]string{}
+	kept := make([]*route53.ResourceRecordSet, 0, len(sets))
+	for _, rr := range sets {
+		label, ok := byName[strings.ToLower(aws.StringValue(rr.Name))]
+		if ok && aws.StringValue(rr.SetIdentifier) == "" {
+			if a := apex[aws.StringValue(rr.Type)]; a != nil && sameRecordData(a, rr) {
+				notes[a] = append(notes[a], label)
+				continue
+			}
+		}
+		kept = append(kept, rr)
+	}
+	return kept, notes
+}
+
+// sameRecordData reports whether two record sets answer identically
+func sameRecordData(a, b *route53.ResourceRecordSet) bool {
+	return aws.Int64Value(a.TTL) == aws.Int64Value(b.TTL) &&
+		reflect.DeepEqual(a.AliasTarget, b.AliasTarget) &&
+		recordTarget(a) == recordTarget(b)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+func TestCollapseApex(t *testing.T) {
+	apexA := plainSet("ear.pm.", "A", 300, "1.2.3.4")
+	apexAAAA := plainSet("ear.pm.", "AAAA", 300, "2001:db8::1")
+	weighted := plainSet("m.ear.pm.", "A", 300, "1.2.3.4")
+	weighted.SetIdentifier = aws.String("blue")
+	sets := []*route53.ResourceRecordSet{
+		apexA,
+		apexAAAA,
+		plainSet("WWW.ear.pm.", "A", 300, "1.2.3.4"),       // same: folded
+		plainSet("www.ear.pm.", "AAAA", 60, "2001:db8::1"), // other TTL: kept
+		plainSet("m.ear.pm.", "AAAA", 300, "2001:db8::1"),  // same: folded
+		weighted, // routing policy: kept
+		plainSet("api.ear.pm.", "A", 300, "1.2.3.4"),          // label not asked for: kept
+		plainSet("www.ear.pm.", "TXT", 300, `"no apex twin"`), // no apex TXT: kept
+	}
+	kept, notes := collapseApex(sets, "ear.pm.", []string{"www", "m"})
+
+	var got []string
+	for _, rr := range kept {
+		got = append(got, setKey(rr))
+	}
+	want := []string{"ear.pm A ", "ear.pm AAAA ", "www.ear.pm AAAA ", "m.ear.pm A blue", "api.ear.pm A ", "www.ear.pm TXT "}
+	if !slices.Equal(got, want) {
+		t.Errorf("kept %q\nwant %q", got, want)
+	}
+	if !slices.Equal(notes[apexA], []string{"www"}) || !slices.Equal(notes[apexAAAA], []string{"m"}) || len(notes) != 2 {
+		t.Errorf("notes %v", notes)
+	}
+}
+
+func TestListRecordsCollapseApex(t *testing.T) {
+	tests := []struct {
+		format, want string
+	}{
+		{outputTable, "NAME                   TYPE  TTL  VALUES   \near.pm. (same as www)  A     300  1.2.3.4  \n"},
+		{outputCSV, "Name,Type,TTL,Values,Same As\near.pm.,A,300,1.2.3.4,www\n"},
+		{outputJSON, `[
+  {
+    "name": "ear.pm.",
+    "type": "A",
+    "ttl": 300,
+    "values": [
+      "1.2.3.4"
+    ],
+    "sameAs": [
+      "www"
+    ]
+  }
+]
+`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			setupTest(t)
+			outputFormat = tt.format
+			m := newMock()
+			m.sets["/hostedzone/Z1"] = []*route53.ResourceRecordSet{
+				plainSet("ear.pm.", "A", 300, "1.2.3.4"),
+				plainSet("www.ear.pm.", "A", 300, "1.2.3.4"),
+			}
+			got, err := captureStdout(t, func() error {
+				return listRecords(context.Background(), m, "ear.pm", recordsOptions{sortKey: sortName, collapseLabels: []string{"www"}})
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
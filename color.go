@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// --color modes
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
+// ANSI styles used in tables and diffs
+const (
+	ansiBold  = "\033[1m"
+	ansiDim   = "\033[2m"
+	ansiRed   = "\033[31m"
+	ansiGreen = "\033[32m"
+	ansiReset = "\033[0m"
+)
+
+// colorFlag is the --color mode
+var colorFlag = colorAuto
+
+// validateColor checks the --color flag
+func validateColor() error {
+	switch colorFlag {
+	case colorAuto, colorAlways, colorNever:
+		return nil
+	}
+	return invalid(fmt.Errorf("unknown color mode %q (want auto, always or never)", colorFlag))
+}
+
+// colorEnabled reports whether tables are colored: --color always/never, or
+// in auto mode when stdout is a terminal and NO_COLOR is unset
+func colorEnabled() bool {
+	switch colorFlag {
+	case colorAlways:
+		return true
+	case colorNever:
+		return false
+	}
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// columnStyles returns the ANSI style of each column of a table with the
+// given header ("" for plain), or nil when color is off
+func columnStyles(header []string) []string {
+	if !colorEnabled() {
+		return nil
+	}
+	styles := make([]string, len(header))
+	for i, h := range header {
+		if h == "TTL" {
+			styles[i] = ansiDim
+		}
+	}
+	return styles
+}
+
+// paint wraps s in style, leaving it untouched when style is ""
+func paint(style, s string) string {
+	if style == "" {
+		return s
+	}
+	return style + s + ansiReset
+}
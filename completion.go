@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/spf13/cobra"
+)
+
+// completionTimeout bounds the API calls behind tab completion so a
+// missing network or bad credentials never stall the shell
+const completionTimeout = 3 * time.Second
+
+// completeZones suggests hosted zone names for a command's first argument.
+// Any failure (no config, no network, access denied) yields no suggestions.
+func completeZones(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	cfg, _, _, err := loadConfigAndSource()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	if requestTimeout == 0 || requestTimeout > completionTimeout {
+		requestTimeout = completionTimeout
+	}
+	svc, err := newRoute53Client(cfg)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+	defer cancel()
+	zones, err := allZones(ctx, svc)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var names []string
+	for _, z := range zones {
+		name := strings.TrimSuffix(aws.StringValue(z.Name), ".")
+		if strings.HasPrefix(name, strings.ToLower(toComplete)) {
+			names = append(names, name)
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+// recordSpec describes a record set to write: either values with a TTL,
+// or an alias to aliasTarget in hosted zone aliasZoneID, which
+// aliasService can fill in
+type recordSpec struct {
+	name   string
+	typ    string
+	values []string
+	ttl    int64
+
+	aliasTarget    string
+	aliasZoneID    string
+	aliasService   string
+	evaluateHealth bool
+}
+
+// validateType checks typ against the record types Route53 supports and
+// returns it upper-cased
+func validateType(typ string) (string, error) {
+	t := strings.ToUpper(typ)
+	if !slices.Contains(route53.RRType_Values(), t) {
+		return "", invalid(fmt.Errorf("unsupported record type %q (want one of %s)",
+			typ, strings.Join(route53.RRType_Values(), ", ")))
+	}
+	return t, nil
+}
+
+// qualifyName turns a record name into an absolute name inside zoneName:
+// "" and "@" mean the apex, names ending in a dot or already inside the zone
+// are taken as-is, anything else is relative to the zone
+func qualifyName(name, zoneName string) string {
+	zoneName = strings.TrimSuffix(zoneName, ".") + "."
+	n := strings.TrimSuffix(name, ".")
+	switch {
+	case n == "" || n == "@":
+		return zoneName
+	case strings.HasSuffix(name, "."),
+		equalNames(n, zoneName),
+		strings.HasSuffix(strings.ToLower(n), "."+strings.ToLower(strings.TrimSuffix(zoneName, "."))):
+		return n + "."
+	}
+	return n + "." + zoneName
+}
+
+// writeRecord writes a single record set into a zone (by ID or domain) and
+// prints the change ID. Without upsert it refuses to touch an existing set;
+// with upsert it creates or replaces the set and notes on stderr which of
+// the two happened.
+func writeRecord(ctx context.Context, svc Route53API, identifier string, spec recordSpec, comment string, upsert bool) error {
+	typ, err := validateType(spec.typ)
+	if err != nil {
+		return err
+	}
+	switch {
+	case spec.aliasTarget != "" && len(spec.values) > 0:
+		return invalid(errors.New("--value cannot be combined with --alias-target"))
+	case spec.aliasTarget == "" && len(spec.values) == 0:
+		return invalid(errors.New("at least one --value (or --alias-target) is required"))
+	case spec.aliasTarget == "" && spec.evaluateHealth:
+		return invalid(errors.New("--evaluate-target-health only applies with --alias-target"))
+	}
+	if err := checkAlias(&spec); err != nil {
+		return err
+	}
+	values, err := validateValues(typ, spec.values)
+	if err != nil {
+		return err
+	}
+
+	zone, _, err := findZone(ctx, svc, identifier)
+	if err != nil {
+		return err
+	}
+
+	fqdn := qualifyName(spec.name, aws.StringValue(zone.Name))
+	existing, err := lookupRecordSets(ctx, svc, aws.StringValue(zone.Id), fqdn, typ)
+	if err != nil {
+		return err
+	}
+	exists := slices.ContainsFunc(existing, func(rr *route53.ResourceRecordSet) bool { return rr.SetIdentifier == nil })
+	action := route53.ChangeActionCreate
+	if upsert {
+		action = route53.ChangeActionUpsert
+	} else if exists {
+		return fmt.Errorf("%s %s already exists; use upsert record to replace it", fqdn, typ)
+	}
+
+	rr := &route53.ResourceRecordSet{
+		Name: aws.String(fqdn),
+		Type: aws.String(typ),
+	}
+	if spec.aliasTarget != "" {
+		rr.AliasTarget = &route53.AliasTarget{
+			DNSName:              aws.String(spec.aliasTarget),
+			HostedZoneId:         aws.String(spec.aliasZoneID),
+			EvaluateTargetHealth: aws.Bool(spec.evaluateHealth),
+		}
+	} else {
+		rr.TTL = aws.Int64(spec.ttl)
+		for _, v := range values {
+			rr.ResourceRecords = append(rr.ResourceRecords, &route53.ResourceRecord{Value: aws.String(v)})
+		}
+	}
+	out, err := svc.ChangeResourceRecordSetsWithContext(ctx, &route53.ChangeResourceRecordSetsInput{
+		HostedZoneId: zone.Id,
+		ChangeBatch: &route53.ChangeBatch{
+			Comment: aws.String(comment),
+			Changes: []*route53.Change{{
+				Action:            aws.String(action),
+				ResourceRecordSet: rr,
+			}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if upsert && !quiet {
+		verb := "Created"
+		if exists {
+			verb = "Updated"
+		}
+		fmt.Fprintf(os.Stderr, "%s %s %s\n", verb, fqdn, typ)
+	}
+	fmt.Println(aws.StringValue(out.ChangeInfo.Id))
+	return waitForChanges(ctx, svc, aws.StringValue(out.ChangeInfo.Id))
+}
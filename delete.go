@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+// errNoSuchRecord is returned when the record set to delete does not exist
+var errNoSuchRecord = errors.New("no such record")
+
+// deleteRecord removes one record set from a zone (by ID or domain). The
+// current set is fetched first because a DELETE must repeat its exact
+// values and TTL. setID picks one set of a routing policy group. Asks for
+// confirmation unless assumeYes.
+func deleteRecord(ctx context.Context, svc Route53API, identifier, name, typ, setID, comment string, assumeYes bool) error {
+	typ, err := validateType(typ)
+	if err != nil {
+		return err
+	}
+
+	zone, _, err := findZone(ctx, svc, identifier)
+	if err != nil {
+		return err
+	}
+	fqdn := qualifyName(name, aws.StringValue(zone.Name))
+
+	sets, err := lookupRecordSets(ctx, svc, aws.StringValue(zone.Id), fqdn, typ)
+	if err != nil {
+		return err
+	}
+	var target *route53.ResourceRecordSet
+	for _, rr := range sets {
+		if aws.StringValue(rr.SetIdentifier) == setID {
+			target = rr
+			break
+		}
+	}
+	if target == nil {
+		if setID == "" && len(sets) > 0 {
+			ids := make([]string, len(sets))
+			for i, rr := range sets {
+				ids[i] = aws.StringValue(rr.SetIdentifier)
+			}
+			return fmt.Errorf("%s %s has several record sets; pick one with --set-identifier (%s)",
+				fqdn, typ, strings.Join(ids, ", "))
+		}
+		return fmt.Errorf("%w: %s %s", errNoSuchRecord, fqdn, typ)
+	}
+
+	desc := fmt.Sprintf("%s %s %s", fqdn, typ, recordTarget(target))
+	if !assumeYes && !confirm("Delete "+desc+"?") {
+		return errors.New("aborted")
+	}
+
+	out, err := svc.ChangeResourceRecordSetsWithContext(ctx, &route53.ChangeResourceRecordSetsInput{
+		HostedZoneId: zone.Id,
+		ChangeBatch: &route53.ChangeBatch{
+			Comment: aws.String(comment),
+			Changes: []*route53.Change{{
+				Action:            aws.String(route53.ChangeActionDelete),
+				ResourceRecordSet: target,
+			}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Println(aws.StringValue(out.ChangeInfo.Id))
+	return waitForChanges(ctx, svc, aws.StringValue(out.ChangeInfo.Id))
+}
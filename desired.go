@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+// awsRegion matches region names such as us-east-1, the only location a
+// desired-state JSON file can express
+var awsRegion = regexp.MustCompile(`^[a-z]{2}(-gov)?-[a-z]+-\d+$`)
+
+// loadDesired reads the desired record sets of the origin zone from file:
+// a JSON array shaped like `list records -o json` output when the name ends
+// in .json, an RFC 1035 zone file otherwise. Reports whether it was a zone
+// file, which cannot express alias or routing-policy sets.
+func loadDesired(file, origin string) ([]*route53.ResourceRecordSet, bool, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, false, err
+	}
+	defer f.Close()
+	if !strings.EqualFold(filepath.Ext(file), ".json") {
+		sets, err := parseZoneFile(f, origin, file)
+		return sets, true, err
+	}
+
+	var records []recordJSON
+	if err := json.NewDecoder(f).Decode(&records); err != nil {
+		return nil, false, invalid(fmt.Errorf("%s: %w", file, err))
+	}
+	sets := make([]*route53.ResourceRecordSet, len(records))
+	first := map[string]int{}
+	for i, rj := range records {
+		if sets[i], err = rj.recordSet(origin); err != nil {
+			return nil, false, invalid(fmt.Errorf("%s: record %d (%s %s): %w", file, i+1, rj.Name, rj.Type, err))
+		}
+		// a later entry would silently replace the earlier one
+		if j, dup := first[setKey(sets[i])]; dup {
+			return nil, false, invalid(fmt.Errorf("%s: record %d (%s %s) repeats record %d; put all values in one entry",
+				file, i+1, rj.Name, rj.Type, j+1))
+		}
+		first[setKey(sets[i])] = i
+	}
+	return sets, false, nil
+}
+
+// inZoneFile reports whether a zone file can express rr; alias and
+// routing-policy sets have no zone-file form
+func inZoneFile(rr *route53.ResourceRecordSet) bool {
+	return rr.AliasTarget == nil && aws.StringValue(rr.SetIdentifier) == ""
+}
+
+// loadDesiredLive loads the desired sets from file and fetches the live
+// sets of zone to compare them with. For a zone file, live sets it cannot
+// express are left out, and a note on stderr says how many.
+func loadDesiredLive(ctx context.Context, svc Route53API, zone *route53.HostedZone, file string) (live, want []*route53.ResourceRecordSet, err error) {
+	want, zoneFile, err := loadDesired(file, aws.StringValue(zone.Name))
+	if err != nil {
+		return nil, nil, err
+	}
+	sets, err := zoneRecordSets(ctx, svc, aws.StringValue(zone.Id))
+	if err != nil {
+		return nil, nil, err
+	}
+	if !zoneFile {
+		return sets, want, nil
+	}
+	var skipped int
+	for _, rr := range sets {
+		if inZoneFile(rr) {
+			live = append(live, rr)
+		} else {
+			skipped++
+		}
+	}
+	if skipped > 0 && !quiet {
+		fmt.Fprintf(os.Stderr, "ignoring %d alias and routing-policy record sets: a zone file cannot express them (use a .json file)\n", skipped)
+	}
+	return live, want, nil
+}
+
+// recordSet converts rj back into a record set of the origin zone; the
+// read-only fields (health, sameAs, explanation) are ignored
+func (rj recordJSON) recordSet(origin string) (*route53.ResourceRecordSet, error) {
+	typ, err := validateType(rj.Type)
+	if err != nil {
+		return nil, err
+	}
+	rr := &route53.ResourceRecordSet{
+		Name: aws.String(strings.ToLower(qualifyName(rj.Name, origin))),
+		Type: aws.String(typ),
+	}
+	if rj.SetIdentifier != "" {
+		rr.SetIdentifier = aws.String(rj.SetIdentifier)
+		rr.Weight = rj.Weight
+		if rj.Failover != "" {
+			rr.Failover = aws.String(rj.Failover)
+		}
+		if rj.HealthCheckID != "" {
+			rr.HealthCheckId = aws.String(rj.HealthCheckID)
+		}
+		switch {
+		case awsRegion.MatchString(rj.Location):
+			rr.Region = aws.String(rj.Location)
+		case rj.Location != "":
+			return nil, fmt.Errorf("location %q is not an AWS region; geolocation sets are not supported", rj.Location)
+		}
+	}
+
+	if rj.AliasTarget != nil {
+		if len(rj.Values) > 0 {
+			return nil, errors.New("has both values and an alias target")
+		}
+		rr.AliasTarget = &route53.AliasTarget{
+			DNSName:              aws.String(rj.AliasTarget.DNSName),
+			HostedZoneId:         aws.String(rj.AliasTarget.HostedZoneID),
+			EvaluateTargetHealth: aws.Bool(rj.AliasTarget.EvaluateTargetHealth),
+		}
+		return rr, nil
+	}
+	if rj.TTL == nil || len(rj.Values) == 0 {
+		return nil, errors.New("needs a ttl and values, or an alias target")
+	}
+	values, err := validateValues(typ, rj.Values)
+	if err != nil {
+		return nil, err
+	}
+	rr.TTL = rj.TTL
+	for _, v := range values {
+		rr.ResourceRecords = append(rr.ResourceRecords, &route53.ResourceRecord{Value: aws.String(v)})
+	}
+	return rr, nil
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+// setChange pairs a live record set with the one wanted in its place: live
+// is nil for an addition, want is nil for a removal
+type setChange struct {
+	live, want *route53.ResourceRecordSet
+}
+
+// setKey identifies a record set within a zone by name, type and set
+// identifier
+func setKey(rr *route53.ResourceRecordSet) string {
+	return strings.ToLower(strings.TrimSuffix(unescapeName(aws.StringValue(rr.Name)), ".")) + " " +
+		aws.StringValue(rr.Type) + " " + aws.StringValue(rr.SetIdentifier)
+}
+
+// recordLines renders rr as sorted, comparable zone-file style lines; two
+// sets are equal when their lines are
+func recordLines(rr *route53.ResourceRecordSet) []string {
+	name := strings.ToLower(unescapeName(aws.StringValue(rr.Name)))
+	typ := aws.StringValue(rr.Type)
+	var lines []string
+	if rr.AliasTarget != nil {
+		line := fmt.Sprintf("%s ALIAS %s %s (zone %s)", name, typ,
+			strings.ToLower(strings.TrimSuffix(aws.StringValue(rr.AliasTarget.DNSName), ".")),
+			strings.TrimPrefix(aws.StringValue(rr.AliasTarget.HostedZoneId), "/hostedzone/"))
+		if aws.BoolValue(rr.AliasTarget.EvaluateTargetHealth) {
+			line += " evaluating target health"
+		}
+		lines = append(lines, line)
+	}
+	for _, r := range rr.ResourceRecords {
+		lines = append(lines, fmt.Sprintf("%s %d IN %s %s", name, aws.Int64Value(rr.TTL), typ, qualifyTarget(typ, aws.StringValue(r.Value))))
+	}
+	sort.Strings(lines)
+	if e := explainRecord(rr); e != "" {
+		lines = append([]string{"; " + e}, lines...)
+	}
+	return lines
+}
+
+// diffRecordSets compares the live record sets of zoneName with the wanted
+// ones and returns the differences ordered by key. The apex NS and SOA,
+// which Route53 manages, are left out unless includeManaged.
+func diffRecordSets(live, want []*route53.ResourceRecordSet, zoneName string, includeManaged bool) []setChange {
+	byKey := map[string]*setChange{}
+	add := func(rr *route53.ResourceRecordSet, isLive bool) {
+		if !includeManaged && isApexNSOrSOA(rr, zoneName) {
+			return
+		}
+		c := byKey[setKey(rr)]
+		if c == nil {
+			c = &setChange{}
+			byKey[setKey(rr)] = c
+		}
+		if isLive {
+			c.live = rr
+		} else {
+			c.want = rr
+		}
+	}
+	for _, rr := range live {
+		add(rr, true)
+	}
+	for _, rr := range want {
+		add(rr, false)
+	}
+
+	keys := make([]string, 0, len(byKey))
+	for k, c := range byKey {
+		if c.live == nil || c.want == nil || !slices.Equal(recordLines(c.live), recordLines(c.want)) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	changes := make([]setChange, len(keys))
+	for i, k := range keys {
+		changes[i] = *byKey[k]
+	}
+	return changes
+}
+
+// diffZone prints how the live zone (by ID or domain) differs from the
+// record sets in file, as a unified-style diff: "-" lines exist only live,
+// "+" lines only in the file. A summary goes to stderr. With a zone file,
+// alias and routing-policy sets are left out of the comparison.
+func diffZone(ctx context.Context, svc Route53API, identifier, file string, includeManaged bool) error {
+	zone, _, err := findZone(ctx, svc, identifier)
+	if err != nil {
+		return err
+	}
+	zoneName := aws.StringValue(zone.Name)
+	live, want, err := loadDesiredLive(ctx, svc, zone, file)
+	if err != nil {
+		return err
+	}
+
+	changes := diffRecordSets(live, want, zoneName, includeManaged)
+	if len(changes) == 0 {
+		if !quiet {
+			fmt.Fprintf(os.Stderr, "%s matches %s\n", strings.TrimSuffix(zoneName, "."), file)
+		}
+		return nil
+	}
+
+	color := colorEnabled()
+	line := func(style, prefix, s string) {
+		if !color {
+			style = ""
+		}
+		fmt.Println(paint(style, prefix+s))
+	}
+	line(ansiBold, "--- ", "live "+strings.TrimSuffix(zoneName, "."))
+	line(ansiBold, "+++ ", file)
+	var added, changed, removed int
+	for _, c := range changes {
+		switch {
+		case c.live == nil:
+			added++
+		case c.want == nil:
+			removed++
+		default:
+			changed++
+		}
+		var old, cur []string
+		if c.live != nil {
+			old = recordLines(c.live)
+		}
+		if c.want != nil {
+			cur = recordLines(c.want)
+		}
+		// lines a changed set keeps are shown as context
+		for _, l := range old {
+			if slices.Contains(cur, l) {
+				line("", " ", l)
+			} else {
+				line(ansiRed, "-", l)
+			}
+		}
+		for _, l := range cur {
+			if !slices.Contains(old, l) {
+				line(ansiGreen, "+", l)
+			}
+		}
+	}
+	if !quiet {
+		fmt.Fprintf(os.Stderr, "%d to add, %d to change, %d to remove\n", added, changed, removed)
+	}
+	return nil
+}
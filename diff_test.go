@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+// diffSummary renders changes as "+key", "-key" or "~key"
+func diffSummary(changes []setChange) []string {
+	out := make([]string, len(changes))
+	for i, c := range changes {
+		switch {
+		case c.live == nil:
+			out[i] = "+" + setKey(c.want)
+		case c.want == nil:
+			out[i] = "-" + setKey(c.live)
+		default:
+			out[i] = "~" + setKey(c.live)
+		}
+	}
+	return out
+}
+
+func TestDiffRecordSets(t *testing.T) {
+	soa := plainSet("ear.pm.", "SOA", 900, "ns-1.awsdns-01.org. awsdns-hostmaster.amazon.com. 1 7200 900 1209600 86400")
+	ns := plainSet("ear.pm.", "NS", 172800, "ns-1.awsdns-01.org.")
+	tests := []struct {
+		name       string
+		live, want []*route53.ResourceRecordSet
+		managed    bool
+		diff       []string
+	}{
+		{"identical", []*route53.ResourceRecordSet{plainSet("ear.pm.", "A", 60, "1.1.1.1")},
+			[]*route53.ResourceRecordSet{plainSet("ear.pm.", "A", 60, "1.1.1.1")}, false, []string{}},
+		{"value order does not matter",
+			[]*route53.ResourceRecordSet{plainSet("ear.pm.", "A", 60, "1.1.1.1", "2.2.2.2")},
+			[]*route53.ResourceRecordSet{plainSet("ear.pm.", "A", 60, "2.2.2.2", "1.1.1.1")}, false, []string{}},
+		{"names compare case-insensitively, hostnames with or without the dot",
+			[]*route53.ResourceRecordSet{plainSet("WWW.ear.pm.", "CNAME", 60, "ear.pm")},
+			[]*route53.ResourceRecordSet{plainSet("www.ear.pm.", "CNAME", 60, "ear.pm.")}, false, []string{}},
+		{"added", nil, []*route53.ResourceRecordSet{plainSet("new.ear.pm.", "TXT", 60, `"x"`)}, false,
+			[]string{"+new.ear.pm TXT "}},
+		{"removed", []*route53.ResourceRecordSet{plainSet("old.ear.pm.", "TXT", 60, `"x"`)}, nil, false,
+			[]string{"-old.ear.pm TXT "}},
+		{"changed value", []*route53.ResourceRecordSet{plainSet("ear.pm.", "A", 60, "1.1.1.1")},
+			[]*route53.ResourceRecordSet{plainSet("ear.pm.", "A", 60, "1.1.1.2")}, false, []string{"~ear.pm A "}},
+		{"changed TTL", []*route53.ResourceRecordSet{plainSet("ear.pm.", "A", 60, "1.1.1.1")},
+			[]*route53.ResourceRecordSet{plainSet("ear.pm.", "A", 300, "1.1.1.1")}, false, []string{"~ear.pm A "}},
+		{"alias to plain", []*route53.ResourceRecordSet{aliasSet("cdn.ear.pm.", "A", "d1.cloudfront.net.")},
+			[]*route53.ResourceRecordSet{plainSet("cdn.ear.pm.", "A", 60, "1.1.1.1")}, false, []string{"~cdn.ear.pm A "}},
+		{"apex NS/SOA left out", []*route53.ResourceRecordSet{soa, ns}, nil, false, []string{}},
+		{"apex NS/SOA with include-managed", []*route53.ResourceRecordSet{soa, ns}, nil, true,
+			[]string{"-ear.pm NS ", "-ear.pm SOA "}},
+		{"sorted by key", nil, []*route53.ResourceRecordSet{
+			plainSet("b.ear.pm.", "A", 60, "1.1.1.1"), plainSet("a.ear.pm.", "TXT", 60, `"x"`), plainSet("a.ear.pm.", "A", 60, "1.1.1.1"),
+		}, false, []string{"+a.ear.pm A ", "+a.ear.pm TXT ", "+b.ear.pm A "}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diffSummary(diffRecordSets(tt.live, tt.want, "ear.pm.", tt.managed))
+			if !slices.Equal(got, tt.diff) {
+				t.Errorf("got %q, want %q", got, tt.diff)
+			}
+		})
+	}
+}
+
+func TestDiffZone(t *testing.T) {
+	tests := []struct {
+		name, file, content string
+		want, note          string
+	}{
+		// the alias and weighted sets of applyMock are only compared when
+		// the file can express them
+		{"json", "want.json", `[{"name": "@", "type": "A", "ttl": 300, "values": ["1.2.3.5"]}]`,
+			"--- live ear.pm\n+++ FILE\n" +
+				"-; api.ear.pm. A [blue]: answers 10.0.0.1, weighted 10\n-api.ear.pm. 60 IN A 10.0.0.1\n" +
+				"-cdn.ear.pm. ALIAS A d1.cloudfront.net (zone Z2FDTNDATAQYW2)\n" +
+				"-ear.pm. 300 IN A 1.2.3.4\n+ear.pm. 300 IN A 1.2.3.5\n", ""},
+		{"zone file", "want.zone", "@ 300 IN A 1.2.3.5\n",
+			"--- live ear.pm\n+++ FILE\n-ear.pm. 300 IN A 1.2.3.4\n+ear.pm. 300 IN A 1.2.3.5\n",
+			"ignoring 2 alias and routing-policy record sets"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTest(t)
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+			var stdout string
+			stderr, err := capture(t, &os.Stderr, func() (err error) {
+				stdout, err = captureStdout(t, func() error {
+					return diffZone(context.Background(), applyMock(), "ear.pm", path, false)
+				})
+				return err
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := strings.Replace(tt.want, "FILE", path, 1); stdout != want {
+				t.Errorf("got\n%s\nwant\n%s", stdout, want)
+			}
+			if !strings.Contains(stderr, tt.note) || (tt.note == "") != !strings.Contains(stderr, "ignoring") {
+				t.Errorf("stderr %q, want note %q", stderr, tt.note)
+			}
+		})
+	}
+}
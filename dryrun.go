@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+// dryRunID stands in for change and zone IDs under --dry-run
+const dryRunID = "DRYRUN"
+
+// dryRunClient passes reads through to the wrapped client but prints every
+// mutating request as JSON instead of sending it, returning placeholder
+// results so commands carry on as if it had succeeded
+type dryRunClient struct {
+	Route53API
+}
+
+// printRequest writes one would-be request to stdout as JSON, labelled by
+// operation and without the many unset (null) SDK fields
+func printRequest(op string, input any) error {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if data, err = json.MarshalIndent(dropNulls(v), "", "  "); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stdout, "# %s (dry run, not sent)\n%s\n", op, data)
+	return nil
+}
+
+// dropNulls removes null object members from decoded JSON, recursively
+func dropNulls(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, e := range t {
+			if e == nil {
+				delete(t, k)
+			} else {
+				t[k] = dropNulls(e)
+			}
+		}
+	case []any:
+		for i, e := range t {
+			t[i] = dropNulls(e)
+		}
+	}
+	return v
+}
+
+func (c dryRunClient) ChangeResourceRecordSetsWithContext(_ aws.Context, in *route53.ChangeResourceRecordSetsInput, _ ...request.Option) (*route53.ChangeResourceRecordSetsOutput, error) {
+	if err := printRequest("ChangeResourceRecordSets", in); err != nil {
+		return nil, err
+	}
+	return &route53.ChangeResourceRecordSetsOutput{ChangeInfo: &route53.ChangeInfo{
+		Id:     aws.String(dryRunID),
+		Status: aws.String(route53.ChangeStatusPending),
+	}}, nil
+}
+
+func (c dryRunClient) CreateHostedZoneWithContext(_ aws.Context, in *route53.CreateHostedZoneInput, _ ...request.Option) (*route53.CreateHostedZoneOutput, error) {
+	if err := printRequest("CreateHostedZone", in); err != nil {
+		return nil, err
+	}
+	return &route53.CreateHostedZoneOutput{
+		HostedZone:    &route53.HostedZone{Id: aws.String("/hostedzone/" + dryRunID), Name: in.Name},
+		DelegationSet: &route53.DelegationSet{},
+		ChangeInfo:    &route53.ChangeInfo{Id: aws.String(dryRunID)},
+	}, nil
+}
+
+func (c dryRunClient) DeleteHostedZoneWithContext(_ aws.Context, in *route53.DeleteHostedZoneInput, _ ...request.Option) (*route53.DeleteHostedZoneOutput, error) {
+	if err := printRequest("DeleteHostedZone", in); err != nil {
+		return nil, err
+	}
+	return &route53.DeleteHostedZoneOutput{ChangeInfo: &route53.ChangeInfo{Id: aws.String(dryRunID)}}, nil
+}
+
+func (c dryRunClient) DeleteHealthCheckWithContext(_ aws.Context, in *route53.DeleteHealthCheckInput, _ ...request.Option) (*route53.DeleteHealthCheckOutput, error) {
+	if err := printRequest("DeleteHealthCheck", in); err != nil {
+		return nil, err
+	}
+	return &route53.DeleteHealthCheckOutput{}, nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// envFileKeys are the only variables an env file may set: the static
+// credentials and the region. Anything else, such as AWS_ENDPOINT_URL or
+// HTTPS_PROXY, could redirect requests and credentials elsewhere.
+var envFileKeys = map[string]bool{
+	"AWS_ACCESS_KEY_ID":     true,
+	"AWS_SECRET_ACCESS_KEY": true,
+	"AWS_REGION":            true,
+	"AWS_DEFAULT_REGION":    true,
+}
+
+// loadEnvFile sets the envFileKeys variables from a dotenv-style file
+// (KEY=VALUE lines, optional "export " prefix, # comments, single/double
+// quoted values). Other keys are skipped with a warning, and variables
+// already present in the real environment are left untouched.
+func loadEnvFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		key, val, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return fmt.Errorf("%s:%d: expected KEY=VALUE", path, n)
+		}
+		val = strings.TrimSpace(val)
+		if len(val) >= 2 && (val[0] == '"' || val[0] == '\'') && val[len(val)-1] == val[0] {
+			val = val[1 : len(val)-1]
+		}
+		if !envFileKeys[key] {
+			if !quiet {
+				fmt.Fprintf(os.Stderr, "warning: %s:%d: ignoring %s; only AWS credentials and region are read\n", path, n, key)
+			}
+			continue
+		}
+		if _, set := os.LookupEnv(key); set {
+			continue
+		}
+		if err := os.Setenv(key, val); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
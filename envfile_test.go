@@ -0,0 +1,111 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// clearEnv unsets keys for the duration of t
+func clearEnv(t *testing.T, keys ...string) {
+	t.Helper()
+	for _, k := range keys {
+		t.Setenv(k, "")
+		os.Unsetenv(k)
+	}
+}
+
+func TestLoadEnvFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		preset  map[string]string
+		want    map[string]string // "" means unset
+		wantErr string
+		warn    string
+	}{
+		{
+			name: "credentials and region",
+			file: "# creds\nAWS_ACCESS_KEY_ID=AKIA\nexport AWS_SECRET_ACCESS_KEY=\"s e\"\n\nAWS_REGION='eu-west-1'\n",
+			want: map[string]string{"AWS_ACCESS_KEY_ID": "AKIA", "AWS_SECRET_ACCESS_KEY": "s e", "AWS_REGION": "eu-west-1"},
+		},
+		{
+			name:   "real environment wins",
+			file:   "AWS_ACCESS_KEY_ID=AKIA\nAWS_DEFAULT_REGION=eu-west-1\n",
+			preset: map[string]string{"AWS_ACCESS_KEY_ID": "REAL"},
+			want:   map[string]string{"AWS_ACCESS_KEY_ID": "REAL", "AWS_DEFAULT_REGION": "eu-west-1"},
+		},
+		{
+			name: "other keys are skipped",
+			file: "AWS_ENDPOINT_URL=https://evil.example\nHTTPS_PROXY=http://evil.example:3128\nAWS_REGION=us-east-1\n",
+			want: map[string]string{"AWS_ENDPOINT_URL": "", "HTTPS_PROXY": "", "AWS_REGION": "us-east-1"},
+			warn: "ignoring AWS_ENDPOINT_URL",
+		},
+		{
+			name:    "malformed line",
+			file:    "AWS_REGION=us-east-1\nnot a pair\n",
+			wantErr: ".env:2: expected KEY=VALUE",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTest(t)
+			clearEnv(t, "AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_REGION", "AWS_DEFAULT_REGION",
+				"AWS_ENDPOINT_URL", "HTTPS_PROXY")
+			for k, v := range tt.preset {
+				t.Setenv(k, v)
+			}
+			path := filepath.Join(t.TempDir(), ".env")
+			if err := os.WriteFile(path, []byte(tt.file), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			stderr, err := capture(t, &os.Stderr, func() error { return loadEnvFile(path) })
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for k, want := range tt.want {
+				if got := os.Getenv(k); got != want {
+					t.Errorf("%s = %q, want %q", k, got, want)
+				}
+			}
+			if !strings.Contains(stderr, tt.warn) || (tt.warn == "" && stderr != "") {
+				t.Errorf("stderr = %q, want %q", stderr, tt.warn)
+			}
+		})
+	}
+}
+
+func TestLoadEnvFileMissing(t *testing.T) {
+	if err := loadEnvFile(filepath.Join(t.TempDir(), "nope.env")); !os.IsNotExist(err) {
+		t.Errorf("err = %v, want not-exist", err)
+	}
+}
+
+// a .env in the working directory is never read without --env-file
+func TestDotEnvNotReadImplicitly(t *testing.T) {
+	clearEnv(t, "AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_PROFILE", "AWS_ENDPOINT_URL")
+	t.Setenv("HOME", t.TempDir())
+	savedPath, savedEnv := configPath, envFile
+	t.Cleanup(func() { configPath, envFile = savedPath, savedEnv })
+	configPath, envFile = "", ""
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte("AWS_ACCESS_KEY_ID=AKIA\nAWS_SECRET_ACCESS_KEY=s\nAWS_ENDPOINT_URL=https://evil.example\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+
+	if _, source, _, _ := loadConfigAndSource(); source == "env" {
+		t.Error("credentials came from the .env")
+	}
+	if os.Getenv("AWS_ENDPOINT_URL") != "" || os.Getenv("AWS_ACCESS_KEY_ID") != "" {
+		t.Error("the .env set variables")
+	}
+}
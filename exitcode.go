@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+)
+
+// exit codes, so scripts can tell failures apart
+const (
+	exitFailure  = 1 // anything not covered below
+	exitConfig   = 2 // no or unreadable configuration
+	exitNotFound = 3 // zone or record does not exist
+	exitAWS      = 4 // Route53/STS rejected the call or was unreachable
+	exitInvalid  = 5 // bad flags, arguments or record values
+)
+
+// exitCategories names each exit code for the "code" of -o json error
+// objects
+var exitCategories = map[int]string{
+	exitFailure:  "failure",
+	exitConfig:   "config",
+	exitNotFound: "notFound",
+	exitAWS:      "aws",
+	exitInvalid:  "invalid",
+}
+
+// codedError attaches an exit code to err without changing its message
+type codedError struct {
+	code int
+	err  error
+}
+
+func (e codedError) Error() string { return e.err.Error() }
+func (e codedError) Unwrap() error { return e.err }
+
+// notFound marks err as a missing zone or record
+func notFound(err error) error { return codedError{exitNotFound, err} }
+
+// invalid marks err as bad user input
+func invalid(err error) error { return codedError{exitInvalid, err} }
+
+// awsNotFound lists AWS error codes that mean the thing asked for is gone
+var awsNotFound = map[string]bool{
+	"NoSuchHostedZone":  true,
+	"NoSuchChange":      true,
+	"NoSuchHealthCheck": true,
+}
+
+// exitCode picks the exit code for err
+func exitCode(err error) int {
+	var ce codedError
+	if errors.As(err, &ce) {
+		return ce.code
+	}
+	if errors.Is(err, errNoSuchRecord) {
+		return exitNotFound
+	}
+	var aerr awserr.Error
+	if errors.As(err, &aerr) {
+		if awsNotFound[aerr.Code()] {
+			return exitNotFound
+		}
+		return exitAWS
+	}
+	return exitFailure
+}
+
+// fatal logs "what: err" like log.Fatalf, with AWS errors made friendly,
+// and exits with the code for err. Under -o json the error is also written
+// to stdout as an error object, so scripts can read one stream.
+func fatal(what string, err error) {
+	msg := fmt.Sprintf("%s: %v", what, friendlyError(err))
+	log.Print(msg)
+	if outputFormat == outputJSON {
+		printJSON(newErrorJSON(msg, err))
+	}
+	os.Exit(exitCode(err))
+}
+
+// newErrorJSON describes err, reported as message, for -o json
+func newErrorJSON(message string, err error) errorJSON {
+	code := exitCode(err)
+	e := errorJSON{}
+	e.Error.Message, e.Error.Code, e.Error.Exit = message, exitCategories[code], code
+	var aerr awserr.Error
+	if errors.As(err, &aerr) {
+		e.Error.AWSCode = aerr.Code()
+	}
+	return e
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+)
+
+func TestErrorJSON(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{errors.New("boom"), `{"error":{"message":"m","code":"failure","exit":1}}`},
+		{codedError{exitConfig, errNoConfig}, `{"error":{"message":"m","code":"config","exit":2}}`},
+		{fmt.Errorf("zone: %w", awserr.New("NoSuchHostedZone", "gone", nil)),
+			`{"error":{"message":"m","code":"notFound","exit":3,"awsCode":"NoSuchHostedZone"}}`},
+		{awserr.New("Throttling", "slow down", nil), `{"error":{"message":"m","code":"aws","exit":4,"awsCode":"Throttling"}}`},
+		{invalid(errors.New("bad")), `{"error":{"message":"m","code":"invalid","exit":5}}`},
+	}
+	for _, tt := range tests {
+		b, err := json.Marshal(newErrorJSON("m", tt.err))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != tt.want {
+			t.Errorf("%v: got %s, want %s", tt.err, b, tt.want)
+		}
+	}
+	// every exit code has a category
+	for code := exitFailure; code <= exitInvalid; code++ {
+		if exitCategories[code] == "" {
+			t.Errorf("exit code %d has no category", code)
+		}
+	}
+}
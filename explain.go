@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+// explainRecord describes a routing-policy record set in plain English,
+// e.g. "routes us-east-1 clients to 1.2.3.4, weighted 30". Simple record
+// sets (no SetIdentifier) return "".
+func explainRecord(rr *route53.ResourceRecordSet) string {
+	if aws.StringValue(rr.SetIdentifier) == "" {
+		return ""
+	}
+
+	target := recordTarget(rr)
+	var parts []string
+	switch {
+	case rr.Region != nil:
+		parts = append(parts, fmt.Sprintf("routes %s clients to %s", aws.StringValue(rr.Region), target))
+	case rr.GeoLocation != nil:
+		parts = append(parts, fmt.Sprintf("routes clients in %s to %s", geoLabel(rr.GeoLocation), target))
+	case rr.CidrRoutingConfig != nil:
+		parts = append(parts, fmt.Sprintf("routes clients in CIDR location %s (collection %s) to %s",
+			aws.StringValue(rr.CidrRoutingConfig.LocationName),
+			aws.StringValue(rr.CidrRoutingConfig.CollectionId), target))
+	case rr.Failover != nil:
+		parts = append(parts, fmt.Sprintf("answers %s as the %s failover target",
+			target, strings.ToLower(aws.StringValue(rr.Failover))))
+	case aws.BoolValue(rr.MultiValueAnswer):
+		parts = append(parts, fmt.Sprintf("returns %s as one of several answers", target))
+	default:
+		parts = append(parts, fmt.Sprintf("answers %s", target))
+	}
+	if rr.Weight != nil {
+		parts = append(parts, fmt.Sprintf("weighted %d", aws.Int64Value(rr.Weight)))
+	}
+	if id := aws.StringValue(rr.HealthCheckId); id != "" {
+		parts = append(parts, fmt.Sprintf("while health check %s passes", id))
+	}
+
+	return fmt.Sprintf("%s %s [%s]: %s", aws.StringValue(rr.Name), aws.StringValue(rr.Type),
+		aws.StringValue(rr.SetIdentifier), strings.Join(parts, ", "))
+}
+
+// recordTarget is what a record set answers with: its values or alias target
+func recordTarget(rr *route53.ResourceRecordSet) string {
+	if rr.AliasTarget != nil {
+		return "alias " + aws.StringValue(rr.AliasTarget.DNSName)
+	}
+	vals := make([]string, len(rr.ResourceRecords))
+	for i, r := range rr.ResourceRecords {
+		vals[i] = aws.StringValue(r.Value)
+	}
+	return strings.Join(vals, ", ")
+}
+
+// geoLabel renders a geolocation as the most specific code set, with "*"
+// (Route53's default location) spelled out
+func geoLabel(g *route53.GeoLocation) string {
+	switch {
+	case g.SubdivisionCode != nil:
+		return aws.StringValue(g.CountryCode) + "-" + aws.StringValue(g.SubdivisionCode)
+	case aws.StringValue(g.CountryCode) == "*":
+		return "any other location"
+	case g.CountryCode != nil:
+		return "country " + aws.StringValue(g.CountryCode)
+	default:
+		return "continent " + aws.StringValue(g.ContinentCode)
+	}
+}
+
+// explainAll returns the explanations of the routing-policy sets among sets
+func explainAll(sets []*route53.ResourceRecordSet) []string {
+	var lines []string
+	for _, rr := range sets {
+		if e := explainRecord(rr); e != "" {
+			lines = append(lines, e)
+		}
+	}
+	return lines
+}
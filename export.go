@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+// export formats accepted by export --format
+const (
+	exportBIND      = "bind"
+	exportTerraform = "tfstate-import"
+)
+
+// resumeSuffix names the sidecar file an export to a file keeps next to it
+// until it completes
+const resumeSuffix = ".resume"
+
+// exportState is the sidecar of an export to a file: where the listing
+// stands and how much of the output file holds complete pages, so that
+// --resume can continue an interrupted export
+type exportState struct {
+	ZoneID    string         `json:"zoneId"`
+	Format    string         `json:"format"`
+	Size      int64          `json:"size"`
+	NextName  string         `json:"nextRecordName,omitempty"`
+	NextType  string         `json:"nextRecordType,omitempty"`
+	NextID    string         `json:"nextRecordIdentifier,omitempty"`
+	Resources map[string]int `json:"resources,omitempty"` // Terraform names used so far
+}
+
+// exportTarget checks format and resolves the zone to export
+func exportTarget(ctx context.Context, svc Route53API, identifier, format string) (*route53.HostedZone, error) {
+	if format != exportBIND && format != exportTerraform {
+		return nil, invalid(fmt.Errorf("unknown export format %q (want %s or %s)", format, exportBIND, exportTerraform))
+	}
+	zone, _, err := findZone(ctx, svc, identifier)
+	return zone, err
+}
+
+// exportZone writes every record set of a zone (by ID or domain) to w in
+// the given format
+func exportZone(ctx context.Context, svc Route53API, identifier, format string, w io.Writer) error {
+	zone, err := exportTarget(ctx, svc, identifier, format)
+	if err != nil {
+		return err
+	}
+	return exportPages(ctx, svc, zone, &exportState{Format: format}, w, nil)
+}
+
+// exportZoneFile exports a zone to path page by page. After each page the
+// sidecar path+resumeSuffix records the listing position and the file size;
+// it is removed once the export completes. With resume the export picks up
+// where the sidecar says, dropping any partly written page first.
+func exportZoneFile(ctx context.Context, svc Route53API, identifier, format, path string, resume bool) error {
+	zone, err := exportTarget(ctx, svc, identifier, format)
+	if err != nil {
+		return err
+	}
+	sidecar := path + resumeSuffix
+	st := &exportState{ZoneID: strings.TrimPrefix(aws.StringValue(zone.Id), "/hostedzone/"), Format: format}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if resume {
+		data, err := os.ReadFile(sidecar)
+		if os.IsNotExist(err) {
+			return invalid(fmt.Errorf("nothing to resume: %s does not exist", sidecar))
+		} else if err != nil {
+			return err
+		}
+		saved := exportState{}
+		if err := json.Unmarshal(data, &saved); err != nil {
+			return fmt.Errorf("%s: %w", sidecar, err)
+		}
+		if saved.ZoneID != st.ZoneID || saved.Format != st.Format {
+			return invalid(fmt.Errorf("%s is from exporting zone %s as %s, not %s as %s",
+				sidecar, saved.ZoneID, saved.Format, st.ZoneID, st.Format))
+		}
+		st, flags = &saved, os.O_WRONLY
+	}
+
+	f, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if resume {
+		if err := f.Truncate(st.Size); err != nil {
+			return err
+		}
+		if _, err := f.Seek(st.Size, io.SeekStart); err != nil {
+			return err
+		}
+		if st.Size > 0 && st.NextName == "" {
+			// only the sidecar's removal was missing
+			return os.Remove(sidecar)
+		}
+	}
+	checkpoint := func() error {
+		size, err := f.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return err
+		}
+		st.Size = size
+		data, _ := json.Marshal(st)
+		// a crash mid-write must not leave a torn sidecar
+		if err := os.WriteFile(sidecar+".tmp", data, 0600); err != nil {
+			return err
+		}
+		return os.Rename(sidecar+".tmp", sidecar)
+	}
+	if err := checkpoint(); err != nil {
+		return err
+	}
+	if err := exportPages(ctx, svc, zone, st, f, checkpoint); err != nil {
+		if !quiet {
+			fmt.Fprintf(os.Stderr, "export of %s stopped after %d bytes; run it again with --resume to continue\n", path, st.Size)
+		}
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Remove(sidecar)
+}
+
+// exportPages lists the zone's record sets from st's position on and writes
+// them to w page by page in st.Format, starting with the zone-file header
+// when at the start. After each page st holds the next position and
+// checkpoint, if any, is called.
+func exportPages(ctx context.Context, svc Route53API, zone *route53.HostedZone, st *exportState, w io.Writer, checkpoint func() error) error {
+	origin := aws.StringValue(zone.Name)
+	zoneID := strings.TrimPrefix(aws.StringValue(zone.Id), "/hostedzone/")
+	in := &route53.ListResourceRecordSetsInput{HostedZoneId: zone.Id, MaxItems: maxItems(0)}
+	fresh := st.NextName == ""
+	if !fresh {
+		in.StartRecordName, in.StartRecordType = aws.String(st.NextName), aws.String(st.NextType)
+		if st.NextID != "" {
+			in.StartRecordIdentifier = aws.String(st.NextID)
+		}
+	}
+	if st.Resources == nil {
+		st.Resources = map[string]int{}
+	}
+	var werr error
+	err := svc.ListResourceRecordSetsPagesWithContext(ctx, in, func(out *route53.ListResourceRecordSetsOutput, last bool) bool {
+		sets := out.ResourceRecordSets
+		switch {
+		case st.Format == exportTerraform:
+			werr = writeTerraformImports(w, zoneID, sets, st.Resources)
+		case fresh:
+			// the apex SOA comes first in Route53's listing order
+			if werr = writeBindHeader(w, origin, zoneID, zoneTTL(sets, origin)); werr == nil {
+				werr = writeBindSets(w, origin, sets)
+			}
+		default:
+			werr = writeBindSets(w, origin, sets)
+		}
+		fresh = false
+		if werr != nil {
+			return false
+		}
+		st.NextName, st.NextType = aws.StringValue(out.NextRecordName), aws.StringValue(out.NextRecordType)
+		st.NextID = aws.StringValue(out.NextRecordIdentifier)
+		if checkpoint != nil {
+			werr = checkpoint()
+		}
+		return werr == nil && !last
+	})
+	if err != nil {
+		return err
+	}
+	if werr != nil {
+		return werr
+	}
+	if fresh && st.Format == exportBIND {
+		// a zone always has its SOA, but an empty listing still gets a header
+		return writeBindHeader(w, origin, zoneID, defaultZoneTTL)
+	}
+	return nil
+}
+
+// nonIdent matches runs of characters not allowed in Terraform resource names
+var nonIdent = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// writeTerraformImports emits one `terraform import` command per record set,
+// using the ZONEID_name_type[_setidentifier] import ID aws_route53_record
+// expects. Resource names are derived from the record and de-duplicated
+// against used, which carries over between pages.
+func writeTerraformImports(w io.Writer, zoneID string, sets []*route53.ResourceRecordSet, used map[string]int) error {
+	for _, rr := range sets {
+		name := strings.TrimSuffix(unescapeName(aws.StringValue(rr.Name)), ".")
+		typ := aws.StringValue(rr.Type)
+		setID := aws.StringValue(rr.SetIdentifier)
+
+		importID := zoneID + "_" + name + "_" + typ
+		label := name + "_" + typ
+		if setID != "" {
+			importID += "_" + setID
+			label += "_" + setID
+		}
+
+		res := strings.Trim(nonIdent.ReplaceAllString(strings.ToLower(strings.ReplaceAll(label, "*", "wildcard")), "_"), "_")
+		if res == "" || (res[0] >= '0' && res[0] <= '9') {
+			res = "r_" + res
+		}
+		if used[res]++; used[res] > 1 {
+			res = fmt.Sprintf("%s_%d", res, used[res])
+		}
+
+		if _, err := fmt.Fprintf(w, "terraform import aws_route53_record.%s '%s'\n", res, importID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+// pagedMock serves record sets pageSize at a time, failing once failAfter
+// pages have been served (0 = never)
+type pagedMock struct {
+	*mockRoute53
+	pageSize, failAfter, served int
+}
+
+func (m *pagedMock) ListResourceRecordSetsPagesWithContext(ctx aws.Context, in *route53.ListResourceRecordSetsInput, fn func(*route53.ListResourceRecordSetsOutput, bool) bool, _ ...request.Option) error {
+	var sets []*route53.ResourceRecordSet
+	m.mockRoute53.ListResourceRecordSetsPagesWithContext(ctx, in, func(out *route53.ListResourceRecordSetsOutput, _ bool) bool {
+		sets = out.ResourceRecordSets
+		return false
+	})
+	for len(sets) > 0 {
+		if m.failAfter > 0 && m.served == m.failAfter {
+			return errors.New("connection reset")
+		}
+		m.served++
+		n := min(m.pageSize, len(sets))
+		out := &route53.ListResourceRecordSetsOutput{ResourceRecordSets: sets[:n]}
+		sets = sets[n:]
+		if len(sets) > 0 {
+			out.IsTruncated, out.NextRecordName, out.NextRecordType = aws.Bool(true), sets[0].Name, sets[0].Type
+		}
+		if !fn(out, len(sets) == 0) {
+			break
+		}
+	}
+	return nil
+}
+
+func newPagedMock(failAfter int) *pagedMock {
+	m := newMock()
+	m.sets["/hostedzone/Z1"] = []*route53.ResourceRecordSet{
+		plainSet("ear.pm.", "SOA", 900, "ns-1.awsdns-01.org. awsdns-hostmaster.amazon.com. 1 7200 900 1209600 86400"),
+		plainSet("ear.pm.", "A", 300, "1.2.3.4"),
+		plainSet("ear.pm.", "MX", 3600, "10 mx.ear.pm."),
+		plainSet("api.ear.pm.", "TXT", 60, `"x"`),
+		plainSet("www.ear.pm.", "CNAME", 60, "ear.pm."),
+	}
+	return &pagedMock{mockRoute53: m, pageSize: 2, failAfter: failAfter}
+}
+
+func TestExportZoneFileResume(t *testing.T) {
+	for _, format := range []string{exportBIND, exportTerraform} {
+		t.Run(format, func(t *testing.T) {
+			setupTest(t)
+			quiet = true
+			dir := t.TempDir()
+			ctx := context.Background()
+
+			whole := filepath.Join(dir, "whole")
+			if err := exportZoneFile(ctx, newPagedMock(0), "ear.pm", format, whole, false); err != nil {
+				t.Fatal(err)
+			}
+			want, _ := os.ReadFile(whole)
+			if _, err := os.Stat(whole + resumeSuffix); !os.IsNotExist(err) {
+				t.Errorf("sidecar left after a complete export: %v", err)
+			}
+
+			path := filepath.Join(dir, "part")
+			if err := exportZoneFile(ctx, newPagedMock(2), "ear.pm", format, path, false); err == nil {
+				t.Fatal("interrupted export succeeded")
+			}
+			if _, err := os.Stat(path + resumeSuffix); err != nil {
+				t.Fatalf("no sidecar after an interrupted export: %v", err)
+			}
+			// a page cut short by the interruption is dropped on resume
+			f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+			f.WriteString("www\t60\tIN\tCNA")
+			f.Close()
+
+			if err := exportZoneFile(ctx, newPagedMock(0), "ear.pm", format, path, true); err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := os.ReadFile(path); string(got) != string(want) {
+				t.Errorf("resumed export\n%s\nwant\n%s", got, want)
+			}
+			if _, err := os.Stat(path + resumeSuffix); !os.IsNotExist(err) {
+				t.Errorf("sidecar left after the resumed export: %v", err)
+			}
+		})
+	}
+}
+
+func TestExportZoneFileResumeRefuses(t *testing.T) {
+	setupTest(t)
+	quiet = true
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "ear.pm.zone")
+	err := exportZoneFile(ctx, newPagedMock(0), "ear.pm", exportBIND, path, true)
+	if err == nil || !strings.Contains(err.Error(), "nothing to resume") {
+		t.Errorf("err = %v, want nothing to resume", err)
+	}
+	exportZoneFile(ctx, newPagedMock(1), "ear.pm", exportBIND, path, false)
+	err = exportZoneFile(ctx, newPagedMock(0), "ear.pm", exportTerraform, path, true)
+	if err == nil || !strings.Contains(err.Error(), "as bind, not Z1 as tfstate-import") {
+		t.Errorf("err = %v, want a format mismatch", err)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+// getRecord prints the values of one record set, one per line, for
+// scripting; alias sets print their target. Sets sharing the name & type
+// under a routing policy are all printed. With first only the first value
+// is printed.
+func getRecord(ctx context.Context, svc Route53API, identifier, name, typ string, first bool) error {
+	typ, err := validateType(typ)
+	if err != nil {
+		return err
+	}
+
+	zone, _, err := findZone(ctx, svc, identifier)
+	if err != nil {
+		return err
+	}
+	fqdn := qualifyName(name, aws.StringValue(zone.Name))
+
+	sets, err := lookupRecordSets(ctx, svc, aws.StringValue(zone.Id), fqdn, typ)
+	if err != nil {
+		return err
+	}
+	var values []string
+	for _, rr := range sets {
+		if rr.AliasTarget != nil {
+			values = append(values, aws.StringValue(rr.AliasTarget.DNSName))
+		}
+		for _, r := range rr.ResourceRecords {
+			values = append(values, aws.StringValue(r.Value))
+		}
+	}
+	if len(values) == 0 {
+		return fmt.Errorf("%w: %s %s", errNoSuchRecord, fqdn, typ)
+	}
+	if first {
+		values = values[:1]
+	}
+	for _, v := range values {
+		fmt.Println(v)
+	}
+	return nil
+}
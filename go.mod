@@ -4,11 +4,19 @@ go 1.24.2
 
 require (
 	github.com/aws/aws-sdk-go v1.55.7
+	github.com/miekg/dns v1.1.62
 	github.com/spf13/cobra v1.9.1
+	golang.org/x/net v0.27.0
+	golang.org/x/term v0.32.0
 )
 
 require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/jmespath/go-jmespath v0.4.0 // indirect
 	github.com/spf13/pflag v1.0.6 // indirect
+	golang.org/x/mod v0.18.0 // indirect
+	golang.org/x/sync v0.7.0 // indirect
+	golang.org/x/sys v0.33.0 // indirect
+	golang.org/x/text v0.16.0 // indirect
+	golang.org/x/tools v0.22.0 // indirect
 )
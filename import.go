@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/miekg/dns"
+)
+
+// lineReader counts the lines read through it. The zone parser reads a
+// ByteReader one byte at a time, so after each record the count is the
+// line that record ends on.
+type lineReader struct {
+	r     *bufio.Reader
+	lines int
+	last  byte
+}
+
+func (l *lineReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	for _, b := range p[:n] {
+		l.count(b)
+	}
+	return n, err
+}
+
+func (l *lineReader) ReadByte() (byte, error) {
+	b, err := l.r.ReadByte()
+	if err == nil {
+		l.count(b)
+	}
+	return b, err
+}
+
+func (l *lineReader) count(b byte) {
+	l.last = b
+	if b == '\n' {
+		l.lines++
+	}
+}
+
+// line returns the line the last byte read is on
+func (l *lineReader) line() int {
+	if l.last == '\n' {
+		return l.lines
+	}
+	return l.lines + 1
+}
+
+// parseZoneFile reads an RFC 1035 master file and groups its records into
+// record sets by name & type, in order of first appearance. Names are
+// resolved against origin unless the file sets its own $ORIGIN. A set
+// takes the TTL of its first record. TXT byte escapes are turned octal, as
+// Route53 reads them.
+func parseZoneFile(r io.Reader, origin, file string) ([]*route53.ResourceRecordSet, error) {
+	sets, _, err := parseZoneFileLines(r, origin, file)
+	return sets, err
+}
+
+// parseZoneFileLines is parseZoneFile that also returns, for each set, the
+// line its first record ends on
+func parseZoneFileLines(r io.Reader, origin, file string) ([]*route53.ResourceRecordSet, []int, error) {
+	lr := &lineReader{r: bufio.NewReader(r)}
+	zp := dns.NewZoneParser(lr, dns.Fqdn(origin), file)
+	var sets []*route53.ResourceRecordSet
+	var lines []int
+	byKey := map[string]*route53.ResourceRecordSet{}
+	for rr, ok := zp.Next(); ok; rr, ok = zp.Next() {
+		h := rr.Header()
+		typ, err := validateType(dns.TypeToString[h.Rrtype])
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", h.Name, err)
+		}
+		name := strings.ToLower(h.Name)
+		if !dns.IsSubDomain(dns.Fqdn(origin), name) {
+			return nil, nil, fmt.Errorf("%s is outside zone %s", h.Name, origin)
+		}
+		value := strings.TrimPrefix(rr.String(), h.String())
+		if typ == route53.RRTypeTxt || typ == route53.RRTypeSpf {
+			// the zone file's \DDD byte escapes are decimal, Route53's octal
+			value = convertEscapes(value, 10, 8)
+		}
+
+		key := name + " " + typ
+		set, seen := byKey[key]
+		if !seen {
+			set = &route53.ResourceRecordSet{
+				Name: aws.String(name),
+				Type: aws.String(typ),
+				TTL:  aws.Int64(int64(h.Ttl)),
+			}
+			byKey[key] = set
+			sets = append(sets, set)
+			lines = append(lines, lr.line())
+		}
+		set.ResourceRecords = append(set.ResourceRecords, &route53.ResourceRecord{Value: aws.String(value)})
+	}
+	if err := zp.Err(); err != nil {
+		return nil, nil, err
+	}
+	return sets, lines, nil
+}
+
+// setLimitProblems returns why Route53 would reject any upsert of rr: too
+// many values or characters for one request, or a value over the per-value
+// length
+func setLimitProblems(rr *route53.ResourceRecordSet) []string {
+	var problems []string
+	upsert := &route53.Change{Action: aws.String(route53.ChangeActionUpsert), ResourceRecordSet: rr}
+	if n, size := changeWeight(upsert); n > maxBatchRecords || size > maxBatchChars {
+		problems = append(problems, fmt.Sprintf("%d values of %d characters exceed one request's limits (%d records, %d characters, upserts count twice)",
+			len(rr.ResourceRecords), size/2, maxBatchRecords, maxBatchChars))
+	}
+	for i, r := range rr.ResourceRecords {
+		if n := len(aws.StringValue(r.Value)); n > maxValueLen {
+			problems = append(problems, fmt.Sprintf("value %d is %d characters, over the limit of %d", i+1, n, maxValueLen))
+		}
+	}
+	return problems
+}
+
+// importZone upserts every record set of a zone file into a zone (by ID or
+// domain), batching as needed. The apex NS and SOA are skipped, with a
+// note saying how many, since Route53 manages them and another provider's
+// would break the delegation; overwriteApex upserts them too. Sets over
+// Route53's limits are reported as file:line before anything is sent;
+// skipInvalid warns about them and imports the rest.
+func importZone(ctx context.Context, svc Route53API, identifier, file, comment string, overwriteApex, skipInvalid bool) error {
+	zone, _, err := findZone(ctx, svc, identifier)
+	if err != nil {
+		return err
+	}
+	zoneName := aws.StringValue(zone.Name)
+
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	sets, lines, err := parseZoneFileLines(f, zoneName, file)
+	if err != nil {
+		return err
+	}
+
+	var changes []*route53.Change
+	var skipped int
+	var problems []string
+	for i, rr := range sets {
+		if !overwriteApex && isApexNSOrSOA(rr, zoneName) {
+			skipped++
+			continue
+		}
+		if p := setLimitProblems(rr); len(p) > 0 {
+			problems = append(problems, fmt.Sprintf("%s:%d: %s %s: %s", file, lines[i],
+				aws.StringValue(rr.Name), aws.StringValue(rr.Type), strings.Join(p, "; ")))
+			continue
+		}
+		changes = append(changes, &route53.Change{
+			Action:            aws.String(route53.ChangeActionUpsert),
+			ResourceRecordSet: rr,
+		})
+	}
+	if skipped > 0 && !quiet {
+		fmt.Fprintf(os.Stderr, "skipped %d apex NS/SOA record sets managed by Route53 (--overwrite-apex imports them)\n", skipped)
+	}
+	if len(problems) > 0 {
+		if !skipInvalid {
+			return invalid(fmt.Errorf("%d record sets exceed Route53 limits, nothing was imported (--skip-invalid imports the rest):\n  %s",
+				len(problems), strings.Join(problems, "\n  ")))
+		}
+		if !quiet {
+			for _, p := range problems {
+				fmt.Fprintln(os.Stderr, "skipping "+p)
+			}
+		}
+	}
+	if len(changes) == 0 {
+		return fmt.Errorf("%s has no records to import", file)
+	}
+
+	ids, err := submitChanges(ctx, svc, aws.StringValue(zone.Id), changes, comment)
+	for _, id := range ids {
+		fmt.Println(id)
+	}
+	if err != nil {
+		return err
+	}
+	return waitForChanges(ctx, svc, ids...)
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+func TestParseZoneFile(t *testing.T) {
+	zone := `$TTL 300
+@	IN	A	1.2.3.4
+@	600	IN	A	1.2.3.5
+www	IN	CNAME	@
+@	IN	TXT	"caf\195\169" "C:\\temp"
+mail.ear.pm.	IN	MX	10 mx
+`
+	sets, err := parseZoneFile(strings.NewReader(zone), "ear.pm", "test.zone")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, rr := range sets {
+		var vals []string
+		for _, r := range rr.ResourceRecords {
+			vals = append(vals, aws.StringValue(r.Value))
+		}
+		got = append(got, aws.StringValue(rr.Name)+" "+aws.StringValue(rr.Type)+" "+strings.Join(vals, " | "))
+		if aws.StringValue(rr.Type) == "A" && aws.Int64Value(rr.TTL) != 300 {
+			t.Errorf("A set TTL %d, want the first record's 300", aws.Int64Value(rr.TTL))
+		}
+	}
+	want := []string{
+		"ear.pm. A 1.2.3.4 | 1.2.3.5",
+		"www.ear.pm. CNAME ear.pm.",
+		`ear.pm. TXT "caf\303\251" "C:\\temp"`,
+		"mail.ear.pm. MX 10 mx.ear.pm.",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestParseZoneFileOutsideZone(t *testing.T) {
+	_, err := parseZoneFile(strings.NewReader("other.org. 300 IN A 1.2.3.4\n"), "ear.pm", "test.zone")
+	if err == nil || !strings.Contains(err.Error(), "outside zone") {
+		t.Errorf("err = %v, want outside zone", err)
+	}
+}
+
+func TestParseZoneFileLines(t *testing.T) {
+	zone := "$TTL 300\n; comment\n\nwww IN A 1.1.1.1\n@ IN SOA ns1.ear.pm. hostmaster.ear.pm. (\n 1 7200\n 900 1209600 86400 )\n" +
+		"www IN A 1.1.1.2\nmail IN MX 10 mx"
+	sets, lines, err := parseZoneFileLines(strings.NewReader(zone), "ear.pm", "test.zone")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a set is placed at its first record, a record at the line it ends on
+	if want := []int{4, 7, 9}; len(sets) != 3 || !slices.Equal(lines, want) {
+		t.Errorf("lines %v, want %v", lines, want)
+	}
+}
+
+// exporting a zone and importing the file gives back the same values
+func TestBindRoundTrip(t *testing.T) {
+	sets := []*route53.ResourceRecordSet{
+		plainSet("ear.pm.", "TXT", 300, `"caf\303\251"`, `"C:\\temp \"x\""`, `"tab\011end"`),
+		plainSet("ear.pm.", "MX", 300, "0 .", "10 mx.ear.pm."),
+		plainSet("_sip._tcp.ear.pm.", "SRV", 300, "0 5 5060 sip.ear.pm."),
+		plainSet("www.ear.pm.", "CNAME", 60, "ear.pm."),
+		plainSet("spf.ear.pm.", "SPF", 60, `"v=spf1 -all"`),
+	}
+	var b strings.Builder
+	if err := writeBindZone(&b, "ear.pm.", "Z1", sets); err != nil {
+		t.Fatal(err)
+	}
+	back, err := parseZoneFile(strings.NewReader(b.String()), "ear.pm.", "export.zone")
+	if err != nil {
+		t.Fatalf("%v\n%s", err, b.String())
+	}
+	if len(back) != len(sets) {
+		t.Fatalf("got %d sets back, want %d\n%s", len(back), len(sets), b.String())
+	}
+	for i, rr := range back {
+		if got, want := recordLines(rr), recordLines(sets[i]); !slices.Equal(got, want) {
+			t.Errorf("set %d came back as\n%s\nwant\n%s", i, strings.Join(got, "\n"), strings.Join(want, "\n"))
+		}
+	}
+}
+
+func TestImportZoneApex(t *testing.T) {
+	const zone = `$TTL 300
+@	IN	SOA	ns1.other.net. hostmaster.ear.pm. 1 7200 900 1209600 86400
+@	IN	NS	ns1.other.net.
+@	IN	A	1.2.3.4
+www	IN	NS	ns1.other.net.
+`
+	tests := []struct {
+		name      string
+		overwrite bool
+		want      []string
+		note      string
+	}{
+		// a delegation below the apex is an ordinary record set
+		{"apex left to Route53", false, []string{"UPSERT ear.pm. A", "UPSERT www.ear.pm. NS"},
+			"skipped 2 apex NS/SOA record sets"},
+		{"overwrite apex", true, []string{"UPSERT ear.pm. SOA", "UPSERT ear.pm. NS", "UPSERT ear.pm. A", "UPSERT www.ear.pm. NS"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTest(t)
+			path := filepath.Join(t.TempDir(), "ear.pm.zone")
+			if err := os.WriteFile(path, []byte(zone), 0600); err != nil {
+				t.Fatal(err)
+			}
+			m := newMock()
+			stderr, err := capture(t, &os.Stderr, func() error {
+				_, err := captureStdout(t, func() error {
+					return importZone(context.Background(), m, "ear.pm", path, "test", tt.overwrite, false)
+				})
+				return err
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, in := range m.changes {
+				got = append(got, changeList(in.ChangeBatch.Changes)...)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+			if !strings.Contains(stderr, tt.note) || (tt.note == "") != (stderr == "") {
+				t.Errorf("stderr %q, want %q", stderr, tt.note)
+			}
+		})
+	}
+}
+
+// sets over Route53's limits are reported with their lines before anything
+// is sent, or skipped with --skip-invalid
+func TestImportZoneLimits(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("$TTL 300\n@ IN A 1.2.3.4\n")
+	for i := 0; i < 501; i++ {
+		fmt.Fprintf(&b, "many IN A 10.0.%d.%d\n", i/256, i%256)
+	}
+	b.WriteString("big IN TXT" + strings.Repeat(` "`+strings.Repeat("a", 250)+`"`, 17) + "\n")
+	for _, skip := range []bool{false, true} {
+		setupTest(t)
+		path := filepath.Join(t.TempDir(), "ear.pm.zone")
+		if err := os.WriteFile(path, []byte(b.String()), 0600); err != nil {
+			t.Fatal(err)
+		}
+		m := newMock()
+		stderr, err := capture(t, &os.Stderr, func() error {
+			_, err := captureStdout(t, func() error {
+				return importZone(context.Background(), m, "ear.pm", path, "test", false, skip)
+			})
+			return err
+		})
+		problems := []string{
+			path + ":3: many.ear.pm. A: 501 values of",
+			path + ":504: big.ear.pm. TXT: value 1 is 4300 characters, over the limit of 4000",
+		}
+		if !skip {
+			if err == nil || exitCode(err) != exitInvalid || len(m.changes) != 0 {
+				t.Fatalf("err = %v, %d batches sent, want an invalid error and none", err, len(m.changes))
+			}
+			for _, p := range problems {
+				if !strings.Contains(err.Error(), p) {
+					t.Errorf("err %q lacks %q", err, p)
+				}
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, in := range m.changes {
+			got = append(got, changeList(in.ChangeBatch.Changes)...)
+		}
+		if !slices.Equal(got, []string{"UPSERT ear.pm. A"}) {
+			t.Errorf("with --skip-invalid sent %v", got)
+		}
+		for _, p := range problems {
+			if !strings.Contains(stderr, "skipping "+p) {
+				t.Errorf("stderr %q lacks %q", stderr, p)
+			}
+		}
+	}
+}
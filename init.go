@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// defaultConfigPath is where init writes when no path is given
+func defaultConfigPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "r53q.json"), nil
+}
+
+// promptConfig asks for the keys and region on the terminal, the secret
+// without echo. The region defaults to --region, then $AWS_REGION, then
+// defaultRegion.
+func promptConfig() (*config, error) {
+	region := regionFlag
+	if region == "" {
+		region = os.Getenv("AWS_REGION")
+	}
+	if region == "" {
+		region = defaultRegion
+	}
+	cfg := &config{AccessKey: ask("AWS access key ID", "")}
+	secret, err := askSecret("AWS secret access key")
+	if err != nil {
+		return nil, err
+	}
+	cfg.SecretKey = secret
+	cfg.Region = ask("Region", region)
+	if cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, errors.New("access key and secret key are required")
+	}
+	return cfg, nil
+}
+
+// initConfig prompts for a config and writes it to path (default
+// defaultConfigPath) with 0600 permissions, returning the path written. An
+// existing file is only replaced when force is set.
+func initConfig(path string, force bool) (string, error) {
+	if path == "" {
+		var err error
+		if path, err = defaultConfigPath(); err != nil {
+			return "", err
+		}
+	}
+	if _, err := os.Stat(path); err == nil && !force {
+		return "", fmt.Errorf("%s already exists, use --force to overwrite", path)
+	}
+	cfg, err := promptConfig()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", err
+	}
+	data, _ := json.MarshalIndent(cfg, "", "  ")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", err
+	}
+	// WriteFile keeps the mode of a file it overwrites
+	return path, os.Chmod(path, 0600)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// answerPrompts feeds answers to the prompts for the duration of t, with
+// os.Stdin a plain file so the secret is read like any other line
+func answerPrompts(t *testing.T, answers string) {
+	t.Helper()
+	f, err := os.CreateTemp(t.TempDir(), "stdin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	savedStdin, savedReader := os.Stdin, stdin
+	t.Cleanup(func() { os.Stdin, stdin = savedStdin, savedReader })
+	os.Stdin, stdin = f, bufio.NewReader(strings.NewReader(answers))
+}
+
+func TestInitConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing bool
+		force    bool
+		wantErr  string
+	}{
+		{"new file in a new directory", false, false, ""},
+		{"existing file kept without --force", true, false, "already exists"},
+		{"existing 0644 file replaced with --force", true, true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			answerPrompts(t, "AKIA\nsecret\neu-west-1\n")
+			path := filepath.Join(t.TempDir(), "sub", "r53q.json")
+			if tt.existing {
+				if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte("{}"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			var got string
+			_, err := capture(t, &os.Stderr, func() (err error) {
+				got, err = initConfig(path, tt.force)
+				return err
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != path {
+				t.Errorf("wrote %s, want %s", got, path)
+			}
+			fi, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mode := fi.Mode().Perm(); mode != 0600 {
+				t.Errorf("mode %o, want 600", mode)
+			}
+			data, _ := os.ReadFile(path)
+			var cfg config
+			if err := json.Unmarshal(data, &cfg); err != nil {
+				t.Fatal(err)
+			}
+			if cfg.AccessKey != "AKIA" || cfg.SecretKey != "secret" || cfg.Region != "eu-west-1" {
+				t.Errorf("wrote %+v", cfg)
+			}
+		})
+	}
+}
@@ -1,17 +1,30 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/sts"
 	"github.com/spf13/cobra"
 )
 
@@ -21,20 +34,99 @@ var (
 	gitCommit  = "none"
 	buildDate  = "unknown"
 
-	showVersion bool
+	showVersion  bool
+	showIdentity bool
+	configPath   string
+	// configProfile picks an entry of a multi-profile r53q.json
+	configProfile string
+	cacheDirFlag  string
+	noAutocreate  bool // deprecated no-op, kept so old scripts still parse
+	envFile       string
+	strict        bool
+	wide          bool
+	humanTTL      bool // list records --human
+	quiet         bool
+	awsProfile    string
+	regionFlag    string
+	// endpointURL points the Route53 client elsewhere (LocalStack, ...);
+	// noVerifySSL skips TLS certificate checks for such endpoints
+	endpointURL  string
+	noVerifySSL  bool
+	outputFormat = outputTable
+
+	// role assumed on top of the base credentials, if any
+	assumeRoleARN   string
+	externalID      string
+	roleSessionName string
+	// stsGlobal sends STS calls to the global sts.amazonaws.com rather than
+	// the regional endpoint; stsRegional only exists to say so explicitly
+	stsGlobal, stsRegional bool
+
+	// timeout bounds a whole command, requestTimeout each HTTP request
+	timeout        time.Duration
+	requestTimeout time.Duration
+
+	// changeCommentFlag overrides the ChangeBatch comment of mutating commands
+	changeCommentFlag string
+
+	// dryRun prints mutating requests instead of sending them
+	dryRun bool
+
+	// maxRetries is how often the SDK retries throttled or failed requests,
+	// backing off exponentially
+	maxRetries = 5
+
+	// pageSize sets MaxItems on list calls (0 = service default)
+	pageSize int
 )
 
-// config holds AWS creds & region
+// errNoConfig is returned when no config file or environment is found
+var errNoConfig = errors.New("no credentials configured: no config file or AWS_* environment variables found (run r53q init)")
+
+// errEmptyResult is returned by list commands in --strict mode when nothing
+// is left to print
+var errEmptyResult = errors.New("no rows matched (--strict)")
+
+// defaultRegion is used when neither --region nor any config sets one;
+// Route53 is global, so any region reaches it
+const defaultRegion = "us-east-1"
+
+// config holds AWS creds & region. When Profile is set, credentials come
+// from the AWS shared config/credentials files instead of the static keys.
 type config struct {
 	AccessKey string `json:"access_key"`
 	SecretKey string `json:"secret_key"`
 	Region    string `json:"region"`
+	Profile   string `json:"-"`
+	// ConfigProfile names the entry of a multi-profile r53q.json in use
+	ConfigProfile string `json:"-"`
+}
+
+// configFile is the r53q.json layout: one flat account, or named accounts
+// under "profiles" with "default" naming the one used without
+// --config-profile
+type configFile struct {
+	config
+	Profiles map[string]config `json:"profiles"`
+	Default  string            `json:"default"`
 }
 
-// loadConfigAndSource locates or creates a config, or loads from env.
-// Returns (*config, source, path, error)
-// source is "file", "env", or "created"
+// loadConfigAndSource locates a config, or loads from env, without side
+// effects. Returns (*config, source, path, error)
+// source is "profile", "file" or "env"; errNoConfig means nothing was found.
+// An explicit --config path skips the search entirely.
 func loadConfigAndSource() (*config, string, string, error) {
+	if configPath != "" {
+		cfg, err := loadconfig(configPath)
+		if err != nil {
+			return nil, "", "", fmt.Errorf("--config: %w", err)
+		}
+		return cfg, "file", configPath, nil
+	}
+	// 0) --profile overrides the r53q.json/env search entirely
+	if awsProfile != "" {
+		return namedProfile(awsProfile)
+	}
 	// 1) next to binary
 	if exe, err := os.Executable(); err == nil {
 		dir := filepath.Dir(exe)
@@ -59,28 +151,197 @@ func loadConfigAndSource() (*config, string, string, error) {
 		cfg, err := loadconfig(etcp)
 		return cfg, "file", etcp, err
 	}
-	// 4) env vars
+	// 4) env vars, optionally seeded from --env-file
+	if envFile != "" {
+		if err := loadEnvFile(envFile); err != nil {
+			return nil, "", "", err
+		}
+	}
 	access := os.Getenv("AWS_ACCESS_KEY_ID")
 	secret := os.Getenv("AWS_SECRET_ACCESS_KEY")
 	region := os.Getenv("AWS_REGION")
 	if region == "" {
 		region = os.Getenv("AWS_DEFAULT_REGION")
 	}
-	if access != "" && secret != "" && region != "" {
-		return &config{access, secret, region}, "env", "", nil
+	if access != "" && secret != "" {
+		return &config{AccessKey: access, SecretKey: secret, Region: region}, "env", "", nil
+	}
+	// 5) $AWS_PROFILE, which is often exported for other tools, only when
+	// r53q has no credentials of its own
+	if p := os.Getenv("AWS_PROFILE"); p != "" {
+		return namedProfile(p)
+	}
+	return nil, "", "", errNoConfig
+}
+
+// namedProfile loads the profile called name: the r53q config
+// ~/.config/r53q/<name>.json if present, else the AWS shared-config
+// profile of that name
+func namedProfile(name string) (*config, string, string, error) {
+	if home, err := os.UserHomeDir(); err == nil {
+		named := filepath.Join(home, ".config", "r53q", name+".json")
+		if _, err := os.Stat(named); err == nil {
+			cfg, err := loadconfig(named)
+			return cfg, "file", named, err
+		}
+	}
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	return &config{Profile: name, Region: region}, "profile", "", nil
+}
+
+// newSession builds an AWS session from the config, applying the
+// per-request timeout to the HTTP client
+func newSession(cfg *config) (*session.Session, error) {
+	awsCfg := aws.Config{MaxRetries: aws.Int(maxRetries)}
+	awsCfg.MergeIn(verboseConfig())
+	if r := configuredRegion(cfg); r != "" {
+		awsCfg.Region = aws.String(r)
+	}
+	if requestTimeout > 0 || noVerifySSL {
+		awsCfg.HTTPClient = &http.Client{Timeout: requestTimeout}
+	}
+	if noVerifySSL {
+		tr := http.DefaultTransport.(*http.Transport).Clone()
+		tr.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+		awsCfg.HTTPClient.Transport = tr
+	}
+	var sess *session.Session
+	var err error
+	if cfg.Profile != "" {
+		// region falls back to the profile's own when not set above
+		sess, err = session.NewSessionWithOptions(session.Options{
+			Config:            awsCfg,
+			Profile:           cfg.Profile,
+			SharedConfigState: session.SharedConfigEnable,
+		})
+	} else {
+		awsCfg.Credentials = credentials.NewStaticCredentials(cfg.AccessKey, cfg.SecretKey, "")
+		sess, err = session.NewSession(&awsCfg)
 	}
-	// 5) none: create empty in cwd
-	cwd, err := os.Getwd()
 	if err != nil {
-		return nil, "", "", err
+		return nil, err
+	}
+	if aws.StringValue(sess.Config.Region) == "" {
+		sess.Config.Region = aws.String(defaultRegion)
 	}
-	p := filepath.Join(cwd, "r53q.json")
-	empty := &config{}
-	data, _ := json.MarshalIndent(empty, "", "  ")
-	if err := os.WriteFile(p, data, 0644); err != nil {
-		return nil, "", "", err
+	logRequests(&sess.Handlers)
+	if assumeRoleARN == "" {
+		return sess, nil
+	}
+	return assumeRole(sess)
+}
+
+// configuredRegion returns --region, else the config's region; "" means
+// the AWS profile's region, or defaultRegion
+func configuredRegion(cfg *config) string {
+	if regionFlag != "" {
+		return regionFlag
 	}
-	return empty, "created", p, nil
+	return cfg.Region
+}
+
+// assumeRole swaps the session's credentials for those of --assume-role-arn,
+// fetching them up front so STS failures are reported as such rather than
+// surfacing from the first Route53 call
+func assumeRole(base *session.Session) (*session.Session, error) {
+	creds := stscreds.NewCredentialsWithClient(stsClient(base), assumeRoleARN, func(p *stscreds.AssumeRoleProvider) {
+		p.RoleSessionName = roleSessionName
+		if externalID != "" {
+			p.ExternalID = aws.String(externalID)
+		}
+	})
+	if _, err := creds.Get(); err != nil {
+		return nil, codedError{exitAWS, fmt.Errorf("assuming role %s: %w", assumeRoleARN, friendlyError(err))}
+	}
+	return base.Copy(&aws.Config{Credentials: creds}), nil
+}
+
+// stsClient returns an STS client for sess. It calls the session region's
+// own endpoint (sts.<region>.amazonaws.com), which is faster and keeps
+// working in accounts that disabled the global one, unless --sts-global.
+func stsClient(sess *session.Session) *sts.STS {
+	mode := endpoints.RegionalSTSEndpoint
+	if stsGlobal {
+		mode = endpoints.LegacySTSEndpoint
+	}
+	return sts.New(sess, &aws.Config{STSRegionalEndpoint: mode})
+}
+
+// Route53API is the subset of the Route53 client r53q calls; commands take
+// it rather than *route53.Route53 so a fake can stand in for AWS
+type Route53API interface {
+	GetHostedZoneWithContext(aws.Context, *route53.GetHostedZoneInput, ...request.Option) (*route53.GetHostedZoneOutput, error)
+	ListHostedZonesPagesWithContext(aws.Context, *route53.ListHostedZonesInput, func(*route53.ListHostedZonesOutput, bool) bool, ...request.Option) error
+	ListResourceRecordSetsPagesWithContext(aws.Context, *route53.ListResourceRecordSetsInput, func(*route53.ListResourceRecordSetsOutput, bool) bool, ...request.Option) error
+	ChangeResourceRecordSetsWithContext(aws.Context, *route53.ChangeResourceRecordSetsInput, ...request.Option) (*route53.ChangeResourceRecordSetsOutput, error)
+	CreateHostedZoneWithContext(aws.Context, *route53.CreateHostedZoneInput, ...request.Option) (*route53.CreateHostedZoneOutput, error)
+	DeleteHostedZoneWithContext(aws.Context, *route53.DeleteHostedZoneInput, ...request.Option) (*route53.DeleteHostedZoneOutput, error)
+	GetHostedZoneCountWithContext(aws.Context, *route53.GetHostedZoneCountInput, ...request.Option) (*route53.GetHostedZoneCountOutput, error)
+	GetHealthCheckStatusWithContext(aws.Context, *route53.GetHealthCheckStatusInput, ...request.Option) (*route53.GetHealthCheckStatusOutput, error)
+	GetChangeWithContext(aws.Context, *route53.GetChangeInput, ...request.Option) (*route53.GetChangeOutput, error)
+	DeleteHealthCheckWithContext(aws.Context, *route53.DeleteHealthCheckInput, ...request.Option) (*route53.DeleteHealthCheckOutput, error)
+}
+
+// newRoute53Client returns a Route53 client for the config; every command
+// goes through here so session options apply uniformly
+func newRoute53Client(cfg *config) (*route53.Route53, error) {
+	sess, err := newSession(cfg)
+	if err != nil {
+		return nil, err
+	}
+	setZoneCacheKey(sess)
+	if e := configuredEndpoint(); e != "" {
+		return route53.New(sess, &aws.Config{Endpoint: aws.String(e)}), nil
+	}
+	return route53.New(sess), nil
+}
+
+// configuredEndpoint returns --endpoint-url, else $AWS_ENDPOINT_URL; ""
+// means the real Route53 endpoint
+func configuredEndpoint() string {
+	if endpointURL != "" {
+		return endpointURL
+	}
+	return os.Getenv("AWS_ENDPOINT_URL")
+}
+
+// commandContext returns the context for one command, carrying the overall
+// --timeout deadline when set
+func commandContext() (context.Context, context.CancelFunc) {
+	if timeout > 0 {
+		return context.WithTimeout(context.Background(), timeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// requireConfig loads the config for a command, exiting if it is unusable
+func requireConfig() *config {
+	cfg, src, _, err := loadConfigAndSource()
+	if err != nil {
+		fatal("config error", codedError{exitConfig, err})
+	}
+	debugf(1, "config from %s", src)
+	return cfg
+}
+
+// requireClient builds the Route53 client for a command, exiting on failure.
+// Under --dry-run it is wrapped so that nothing is changed.
+func requireClient(cfg *config) Route53API {
+	svc, err := newRoute53Client(cfg)
+	if err != nil {
+		// failing before any Route53 call means a bad profile or the like
+		if exitCode(err) == exitFailure {
+			err = codedError{exitConfig, err}
+		}
+		fatal("client error", err)
+	}
+	if dryRun {
+		return dryRunClient{svc}
+	}
+	return svc
 }
 
 // loadconfig reads AWS creds & region from JSON file
@@ -90,185 +351,874 @@ func loadconfig(path string) (*config, error) {
 		return nil, err
 	}
 	defer f.Close()
-	var cfg config
-	if err := json.NewDecoder(f).Decode(&cfg); err != nil {
+	// the file may hold a secret key; Windows has no mode bits to check
+	if fi, err := f.Stat(); err == nil && runtime.GOOS != "windows" && fi.Mode().Perm()&^0600 != 0 {
+		fmt.Fprintf(os.Stderr, "warning: %s has mode %04o; run chmod 600 on it\n", path, fi.Mode().Perm())
+	}
+	var file configFile
+	if err := json.NewDecoder(f).Decode(&file); err != nil {
 		return nil, err
 	}
-	return &cfg, nil
+	return file.pick(configProfile)
 }
 
-// listZones prints a nice table of all hosted zones
-func listZones(cfg *config) error {
-	sess, err := session.NewSession(&aws.Config{
-		Region:      aws.String(cfg.Region),
-		Credentials: credentials.NewStaticCredentials(cfg.AccessKey, cfg.SecretKey, ""),
+// pick returns the account to use: the profile called name, else the
+// default one, else the flat keys. A file of profiles with neither a
+// default nor flat keys must hold exactly one profile.
+func (f *configFile) pick(name string) (*config, error) {
+	names := make([]string, 0, len(f.Profiles))
+	for n := range f.Profiles {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	if name == "" {
+		name = f.Default
+	}
+	switch {
+	case name != "":
+		cfg, ok := f.Profiles[name]
+		if !ok {
+			if len(names) == 0 {
+				return nil, fmt.Errorf("no config profile %q: the file has no profiles", name)
+			}
+			return nil, fmt.Errorf("no config profile %q (have %s)", name, strings.Join(names, ", "))
+		}
+		cfg.ConfigProfile = name
+		return &cfg, nil
+	case f.AccessKey != "" || len(names) == 0:
+		return &f.config, nil
+	case len(names) == 1:
+		cfg := f.Profiles[names[0]]
+		cfg.ConfigProfile = names[0]
+		return &cfg, nil
+	}
+	return nil, fmt.Errorf("several config profiles and no default; pick one with --config-profile (%s)", strings.Join(names, ", "))
+}
+
+// apiWorkers bounds concurrent Route53 calls so we stay under the
+// 5 requests/second account limit
+const apiWorkers = 5
+
+// parallel calls fn(0..n-1) on at most apiWorkers goroutines
+func parallel(n int, fn func(i int)) {
+	parallelN(apiWorkers, n, fn)
+}
+
+// parallelN calls fn(0..n-1) on at most workers goroutines
+func parallelN(workers, n int, fn func(i int)) {
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// maxItems returns the MaxItems for a list call: --page-size, lowered to
+// limit when that needs fewer items; nil leaves the service default
+func maxItems(limit int) *string {
+	n := pageSize
+	if limit > 0 && (n == 0 || limit < n) {
+		n = limit
+	}
+	if n == 0 {
+		return nil
+	}
+	return aws.String(strconv.Itoa(n))
+}
+
+// countRecords walks a zone and returns its actual number of record sets
+func countRecords(ctx context.Context, svc Route53API, zoneID string) (int64, error) {
+	var n int64
+	err := svc.ListResourceRecordSetsPagesWithContext(ctx, &route53.ListResourceRecordSetsInput{
+		HostedZoneId: aws.String(zoneID),
+		MaxItems:     maxItems(0),
+	}, func(out *route53.ListResourceRecordSetsOutput, last bool) bool {
+		n += int64(len(out.ResourceRecordSets))
+		return !last
 	})
-	if err != nil {
-		return err
+	return n, err
+}
+
+// zonesByPrivacy returns the zones that are private, or public if !private
+func zonesByPrivacy(zones []*route53.HostedZone, private bool) []*route53.HostedZone {
+	var kept []*route53.HostedZone
+	for _, z := range zones {
+		if (z.Config != nil && aws.BoolValue(z.Config.PrivateZone)) == private {
+			kept = append(kept, z)
+		}
 	}
-	svc := route53.New(sess)
+	return kept
+}
 
-	rows := [][]string{{"ID", "Name", "Records"}}
-	if err := svc.ListHostedZonesPages(&route53.ListHostedZonesInput{},
+// zoneNameServerSets fetches the delegation set of each public zone with
+// concurrent GetHostedZone calls; private zones have none and get nil
+func zoneNameServerSets(ctx context.Context, svc Route53API, zones []*route53.HostedZone) ([][]string, error) {
+	sets := make([][]string, len(zones))
+	errs := make([]error, len(zones))
+	parallel(len(zones), func(i int) {
+		if zones[i].Config != nil && aws.BoolValue(zones[i].Config.PrivateZone) {
+			return
+		}
+		out, err := svc.GetHostedZoneWithContext(ctx, &route53.GetHostedZoneInput{Id: zones[i].Id})
+		if err != nil {
+			errs[i] = err
+			return
+		}
+		if out.DelegationSet != nil {
+			sets[i] = aws.StringValueSlice(out.DelegationSet.NameServers)
+		}
+	})
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", aws.StringValue(zones[i].Name), err)
+		}
+	}
+	return sets, nil
+}
+
+// liveRecordCounts counts the records of every zone concurrently,
+// returning counts in the same order as ids
+func liveRecordCounts(ctx context.Context, svc Route53API, ids []string) ([]int64, error) {
+	counts := make([]int64, len(ids))
+	errs := make([]error, len(ids))
+	parallel(len(ids), func(i int) {
+		counts[i], errs[i] = countRecords(ctx, svc, ids[i])
+	})
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("counting %s: %w", ids[i], err)
+		}
+	}
+	return counts, nil
+}
+
+// zonesOptions tweaks how listZones counts & renders zones
+type zonesOptions struct {
+	// liveCounts makes the Records column the actual number of record sets
+	// (one extra API walk per zone) rather than ResourceRecordSetCount,
+	// which can lag behind recent changes
+	liveCounts bool
+	// total adds a line with the record sets summed over all zones; with
+	// --quiet only that number is printed
+	total bool
+	// limit stops after this many zones (0 = all)
+	limit int
+	// nameOnly and idOnly print bare domains (no trailing dot) or bare zone
+	// IDs, one per line, for xargs and friends
+	nameOnly, idOnly bool
+	// private and public keep only zones of that kind
+	private, public bool
+	// withNS adds each public zone's name servers, one GetHostedZone call
+	// per zone
+	withNS bool
+}
+
+// listZones prints all hosted zones in the --output format
+func listZones(ctx context.Context, svc Route53API, opts zonesOptions) error {
+	if opts.limit < 0 {
+		return invalid(errors.New("--limit must not be negative"))
+	}
+	header := []string{"ID", "Name", "Records"}
+	if wide {
+		header = append(header, "Private", "Comment")
+	}
+	if opts.withNS {
+		header = append(header, "Name Servers")
+	}
+	// a filtered listing needs full pages to fill --limit
+	pageLimit := opts.limit
+	if opts.private || opts.public {
+		pageLimit = 0
+	}
+	var zones []*route53.HostedZone
+	var truncated bool
+	if err := svc.ListHostedZonesPagesWithContext(ctx, &route53.ListHostedZonesInput{MaxItems: maxItems(pageLimit)},
 		func(out *route53.ListHostedZonesOutput, last bool) bool {
-			for _, z := range out.HostedZones {
-				rows = append(rows, []string{
-					strings.TrimPrefix(aws.StringValue(z.Id), "/hostedzone/"),
-					aws.StringValue(z.Name),
-					fmt.Sprintf("%d", aws.Int64Value(z.ResourceRecordSetCount)),
-				})
-			}
-			return !last
+			page := out.HostedZones
+			if opts.private || opts.public {
+				page = zonesByPrivacy(page, opts.private)
+			}
+			page, truncated = limitPage(page, len(zones), opts.limit, last)
+			zones = append(zones, page...)
+			return !last && !truncated
 		}); err != nil {
 		return err
 	}
 
-	// align columns
-	widths := make([]int, len(rows[0]))
+	if strict && len(zones) == 0 {
+		return errEmptyResult
+	}
+	if opts.nameOnly || opts.idOnly {
+		for _, z := range zones {
+			if opts.nameOnly {
+				fmt.Println(strings.TrimSuffix(aws.StringValue(z.Name), "."))
+			} else {
+				fmt.Println(strings.TrimPrefix(aws.StringValue(z.Id), "/hostedzone/"))
+			}
+		}
+		return nil
+	}
+
+	counts := make([]int64, len(zones))
+	for i, z := range zones {
+		counts[i] = aws.Int64Value(z.ResourceRecordSetCount)
+	}
+	if opts.liveCounts {
+		if !quiet {
+			fmt.Fprintln(os.Stderr, "warning: --live-counts makes one extra API call per zone")
+		}
+		ids := make([]string, len(zones))
+		for i, z := range zones {
+			ids[i] = aws.StringValue(z.Id)
+		}
+		live, err := liveRecordCounts(ctx, svc, ids)
+		if err != nil {
+			return err
+		}
+		counts = live
+	}
+
+	var nameServers [][]string
+	if opts.withNS {
+		var err error
+		if nameServers, err = zoneNameServerSets(ctx, svc, zones); err != nil {
+			return err
+		}
+	}
+
+	var sum int64
+	for _, c := range counts {
+		sum += c
+	}
+	if opts.total && quiet {
+		fmt.Println(sum)
+		return nil
+	}
+
+	rows := make([][]string, len(zones))
+	objs := make([]any, len(zones))
+	for i, z := range zones {
+		rows[i] = []string{
+			strings.TrimPrefix(aws.StringValue(z.Id), "/hostedzone/"),
+			aws.StringValue(z.Name),
+			fmt.Sprintf("%d", counts[i]),
+		}
+		if wide {
+			var private bool
+			var comment string
+			if z.Config != nil {
+				private = aws.BoolValue(z.Config.PrivateZone)
+				comment = aws.StringValue(z.Config.Comment)
+			}
+			rows[i] = append(rows[i], fmt.Sprintf("%t", private), comment)
+		}
+		zj := newZoneJSON(z, counts[i])
+		if opts.withNS {
+			sep := ", "
+			if outputFormat == outputCSV {
+				sep = ";"
+			}
+			rows[i] = append(rows[i], strings.Join(nameServers[i], sep))
+			zj.NameServers = nameServers[i]
+		}
+		objs[i] = zj
+	}
+
+	w := newListWriter(header, false)
+	if err := w.addPage(rows, objs); err != nil {
+		return err
+	}
+	if err := w.close(); err != nil {
+		return err
+	}
+	if opts.total {
+		printFooter("Total: %d record sets in %d zones", sum, len(zones))
+	}
+	if truncated && !quiet {
+		if out, err := svc.GetHostedZoneCountWithContext(ctx, &route53.GetHostedZoneCountInput{}); err == nil {
+			printFooter("... (showing %d of %d)", len(zones), aws.Int64Value(out.HostedZoneCount))
+		} else {
+			printFooter("... (showing first %d)", len(zones))
+		}
+	}
+	return nil
+}
+
+// columnWidths returns the widest cell of each column
+func columnWidths(rows [][]string) []int {
+	var widths []int
 	for _, r := range rows {
 		for i, c := range r {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
 			if len(c) > widths[i] {
 				widths[i] = len(c)
 			}
 		}
 	}
-	for ri, r := range rows {
-		for i, c := range r {
-			cell := c
-			if ri == 0 {
-				cell = strings.ToUpper(c)
+	return widths
+}
+
+// printRow prints one aligned row; header rows are uppercased. With
+// styles (see columnStyles) cells are colored after padding, so escape
+// codes never count towards the widths, and header rows are bold.
+func printRow(widths []int, styles []string, r []string, header bool) {
+	for i, c := range r {
+		cell := fmt.Sprintf("%-*s", widths[i], c)
+		if header {
+			cell = strings.ToUpper(cell)
+		}
+		if styles != nil {
+			style := styles[i]
+			if header {
+				style = ansiBold
 			}
-			fmt.Printf("%-*s  ", widths[i], cell)
+			cell = paint(style, cell)
 		}
-		fmt.Println()
+		fmt.Print(cell + "  ")
 	}
-	return nil
+	fmt.Println()
 }
 
-// listRecords prints all records in a zone (by ID or domain)
-func listRecords(cfg *config, identifier string) error {
-	sess, err := session.NewSession(&aws.Config{
-		Region:      aws.String(cfg.Region),
-		Credentials: credentials.NewStaticCredentials(cfg.AccessKey, cfg.SecretKey, ""),
-	})
-	if err != nil {
-		return err
+// printTable aligns & prints rows under header; with --quiet the header is
+// left out
+func printTable(header []string, rows [][]string) {
+	styles := columnStyles(header)
+	if quiet {
+		header = nil
+	}
+	widths := columnWidths(append([][]string{header}, rows...))
+	if header != nil {
+		printRow(widths, styles, header, true)
+	}
+	for _, r := range rows {
+		printRow(widths, styles, r, false)
+	}
+}
+
+// recordRow flattens a record set into a table row. Values are joined with
+// ", ", or with ";" in CSV where commas are common inside TXT values. Alias
+// sets show their target and "-" for the TTL.
+func recordRow(rr *route53.ResourceRecordSet) []string {
+	vals := make([]string, len(rr.ResourceRecords))
+	for i, r := range rr.ResourceRecords {
+		vals[i] = aws.StringValue(r.Value)
+	}
+	sep := ", "
+	if outputFormat == outputCSV {
+		sep = ";"
+	}
+	// aliases have no TTL or values of their own
+	if at := rr.AliasTarget; at != nil {
+		return []string{
+			aws.StringValue(rr.Name),
+			aws.StringValue(rr.Type),
+			"-",
+			fmt.Sprintf("ALIAS -> %s (zone %s)", aws.StringValue(at.DNSName), aws.StringValue(at.HostedZoneId)),
+		}
+	}
+	return []string{
+		aws.StringValue(rr.Name),
+		aws.StringValue(rr.Type),
+		formatTTL(aws.Int64Value(rr.TTL)),
+		strings.Join(vals, sep),
+	}
+}
+
+// formatTTL renders a TTL in seconds, or under --human in table output as
+// a duration such as 1d, 5m or 1h30m
+func formatTTL(ttl int64) string {
+	if !humanTTL || outputFormat != outputTable || ttl <= 0 {
+		return strconv.FormatInt(ttl, 10)
+	}
+	var b strings.Builder
+	for _, u := range []struct {
+		secs int64
+		unit string
+	}{{86400, "d"}, {3600, "h"}, {60, "m"}, {1, "s"}} {
+		if n := ttl / u.secs; n > 0 {
+			fmt.Fprintf(&b, "%d%s", n, u.unit)
+			ttl %= u.secs
+		}
+	}
+	return b.String()
+}
+
+// explodeRows renders a record set as one row per value
+func explodeRows(rr *route53.ResourceRecordSet) [][]string {
+	base := recordRow(rr)
+	if len(rr.ResourceRecords) < 2 {
+		return [][]string{base}
+	}
+	rows := make([][]string, len(rr.ResourceRecords))
+	for i, r := range rr.ResourceRecords {
+		rows[i] = []string{base[0], base[1], base[2], aws.StringValue(r.Value)}
+	}
+	return rows
+}
+
+// splitPriorityRows renders a record set with separate Priority, Weight and
+// Port columns. MX ("pri target") and SRV ("pri weight port target") values
+// get one row each; other types keep a single row with those columns blank.
+func splitPriorityRows(rr *route53.ResourceRecordSet) [][]string {
+	base := recordRow(rr)
+	typ := aws.StringValue(rr.Type)
+	if typ != "MX" && typ != "SRV" {
+		return [][]string{{base[0], base[1], base[2], "", "", "", base[3]}}
+	}
+	var rows [][]string
+	for _, r := range rr.ResourceRecords {
+		f := strings.Fields(aws.StringValue(r.Value))
+		switch {
+		case typ == "MX" && len(f) == 2:
+			rows = append(rows, []string{base[0], base[1], base[2], f[0], "", "", f[1]})
+		case typ == "SRV" && len(f) == 4:
+			rows = append(rows, []string{base[0], base[1], base[2], f[0], f[1], f[2], f[3]})
+		default:
+			// malformed value, show it untouched
+			rows = append(rows, []string{base[0], base[1], base[2], "", "", "", aws.StringValue(r.Value)})
+		}
+	}
+	return rows
+}
+
+// routingCells returns the --wide routing columns of a record set:
+// set identifier, weight, location (latency region or geolocation),
+// failover role and health check ID
+func routingCells(rr *route53.ResourceRecordSet) []string {
+	var weight, location string
+	if rr.Weight != nil {
+		weight = fmt.Sprintf("%d", aws.Int64Value(rr.Weight))
+	}
+	switch {
+	case rr.Region != nil:
+		location = aws.StringValue(rr.Region)
+	case rr.GeoLocation != nil:
+		location = geoLabel(rr.GeoLocation)
+	}
+	return []string{
+		aws.StringValue(rr.SetIdentifier),
+		weight,
+		location,
+		aws.StringValue(rr.Failover),
+		aws.StringValue(rr.HealthCheckId),
+	}
+}
+
+// recordsOptions tweaks how listRecords fetches & renders a zone
+type recordsOptions struct {
+	// stream prints rows as each page arrives instead of buffering; column
+	// widths are sampled from the first page only, so later rows with
+	// longer cells will not line up
+	stream bool
+	// withHealth adds a Health column resolved via GetHealthCheckStatus
+	withHealth bool
+	// explain describes each routing-policy set in plain English: lines
+	// after the table, or an extra field/column in JSON/CSV
+	explain bool
+	// splitPriority breaks MX/SRV values into Priority/Weight/Port columns,
+	// one row per value
+	splitPriority bool
+	// explode prints one row per value instead of joining them
+	explode bool
+	// valueFilter keeps only sets with a value (or alias target) containing
+	// this substring, case-insensitively
+	valueFilter string
+	// nameFilter keeps only sets whose name contains this substring or,
+	// when it has glob metacharacters, matches it as a path.Match pattern
+	nameFilter string
+	// typeFilter keeps only sets of this record type
+	typeFilter string
+	// limit stops after this many record sets (0 = all), fetching no
+	// further pages
+	limit int
+	// sortKey orders buffered output by name, type or ttl; reverse flips it
+	sortKey string
+	reverse bool
+	// collapseLabels folds <label>.<zone> sets that duplicate the apex into
+	// the apex row (buffered output only)
+	collapseLabels []string
+}
+
+// filterRecordSets drops the record sets that do not match opts' filters;
+// a set is kept only if it passes every filter that is set
+func filterRecordSets(sets []*route53.ResourceRecordSet, zoneName string, opts recordsOptions) []*route53.ResourceRecordSet {
+	if opts.valueFilter == "" && opts.nameFilter == "" && opts.typeFilter == "" {
+		return sets
 	}
-	svc := route53.New(sess)
+	needle := strings.ToLower(opts.valueFilter)
+	var kept []*route53.ResourceRecordSet
+	for _, rr := range sets {
+		if opts.typeFilter != "" && aws.StringValue(rr.Type) != opts.typeFilter {
+			continue
+		}
+		if opts.nameFilter != "" && !nameMatches(rr, zoneName, opts.nameFilter) {
+			continue
+		}
+		if needle != "" && !valueMatches(rr, needle) {
+			continue
+		}
+		kept = append(kept, rr)
+	}
+	return kept
+}
 
-	dom := identifier
-	isDomain := strings.Contains(identifier, ".")
-	if isDomain && !strings.HasSuffix(dom, ".") {
-		dom += "."
+// nameMatches reports whether rr's name contains pattern or, if pattern
+// has glob metacharacters, matches it either as a full name or relative to
+// the zone. Comparison ignores case and the trailing dot.
+func nameMatches(rr *route53.ResourceRecordSet, zoneName, pattern string) bool {
+	pattern = strings.ToLower(strings.TrimSuffix(pattern, "."))
+	name := strings.ToLower(strings.TrimSuffix(unescapeName(aws.StringValue(rr.Name)), "."))
+	if !strings.ContainsAny(pattern, "*?[") {
+		return strings.Contains(name, pattern)
+	}
+	if ok, _ := path.Match(pattern, name); ok {
+		return true
+	}
+	zone := strings.ToLower(strings.TrimSuffix(zoneName, "."))
+	rel := strings.TrimSuffix(strings.TrimSuffix(name, zone), ".")
+	ok, _ := path.Match(pattern, rel)
+	return ok
+}
+
+// valueMatches reports whether any value or the alias target of rr
+// contains the lowercase needle
+func valueMatches(rr *route53.ResourceRecordSet, needle string) bool {
+	for _, r := range rr.ResourceRecords {
+		if strings.Contains(strings.ToLower(aws.StringValue(r.Value)), needle) {
+			return true
+		}
+	}
+	return rr.AliasTarget != nil &&
+		strings.Contains(strings.ToLower(aws.StringValue(rr.AliasTarget.DNSName)), needle)
+}
+
+// listRecords prints all records in a zone (by ID or domain) in the
+// --output format
+func listRecords(ctx context.Context, svc Route53API, identifier string, opts recordsOptions) error {
+	if _, err := path.Match(opts.nameFilter, ""); err != nil {
+		return fmt.Errorf("bad --name pattern %q: %w", opts.nameFilter, err)
+	}
+	if err := validateSort(opts.sortKey); err != nil {
+		return err
+	}
+	if opts.limit < 0 {
+		return invalid(errors.New("--limit must not be negative"))
+	}
+	if opts.typeFilter != "" {
+		typ, err := validateType(opts.typeFilter)
+		if err != nil {
+			return err
+		}
+		opts.typeFilter = typ
 	}
 
 	// resolve zone ID
-	outZones, err := svc.ListHostedZones(&route53.ListHostedZonesInput{})
+	zone, _, err := findZone(ctx, svc, identifier)
 	if err != nil {
 		return err
 	}
-	var zoneID string
-	for _, z := range outZones.HostedZones {
-		idVal := aws.StringValue(z.Id)
-		nameVal := aws.StringValue(z.Name)
-		if (isDomain && nameVal == dom) ||
-			(!isDomain && (idVal == identifier || idVal == "/hostedzone/"+identifier)) {
-			zoneID = idVal
-			break
-		}
+	zoneID := aws.StringValue(zone.Id)
+
+	header := []string{"Name", "Type", "TTL", "Values"}
+	if opts.splitPriority {
+		header = []string{"Name", "Type", "TTL", "Priority", "Weight", "Port", "Values"}
+	}
+	if wide {
+		header = append(header, "Set ID", "Weight", "Location", "Failover", "Health Check")
+	}
+	if opts.withHealth {
+		header = append(header, "Health")
+	}
+
+	csvExplain := opts.explain && outputFormat == outputCSV
+	tableExplain := opts.explain && outputFormat == outputTable
+	if csvExplain {
+		header = append(header, "Explanation")
+	}
+	// the table notes folded labels beside the name; CSV gets a column so
+	// the name stays a name
+	csvSameAs := len(opts.collapseLabels) > 0 && outputFormat == outputCSV
+	if csvSameAs {
+		header = append(header, "Same As")
 	}
-	if zoneID == "" {
-		return fmt.Errorf("no hosted zone found for %q", identifier)
+
+	// render turns fetched record sets into table/CSV rows & JSON objects
+	var collapsed map[*route53.ResourceRecordSet][]string
+	render := func(sets []*route53.ResourceRecordSet) ([][]string, []any, error) {
+		var health map[string]string
+		if opts.withHealth {
+			health = healthStatuses(ctx, svc, sets)
+		}
+		rows := make([][]string, 0, len(sets))
+		objs := make([]any, 0, len(sets))
+		for _, rr := range sets {
+			status := health[aws.StringValue(rr.HealthCheckId)]
+			rj := newRecordJSON(rr)
+			rj.Health = status
+			rj.SameAs = collapsed[rr]
+			if opts.explain {
+				rj.Explanation = explainRecord(rr)
+			}
+			objs = append(objs, rj)
+
+			setRows := [][]string{recordRow(rr)}
+			switch {
+			case opts.splitPriority:
+				setRows = splitPriorityRows(rr)
+			case opts.explode:
+				setRows = explodeRows(rr)
+			}
+			for _, row := range setRows {
+				if labels := collapsed[rr]; len(labels) > 0 && outputFormat == outputTable {
+					row[0] += " (same as " + strings.Join(labels, ", ") + ")"
+				}
+				if wide {
+					row = append(row, routingCells(rr)...)
+				}
+				if opts.withHealth {
+					row = append(row, status)
+				}
+				if csvExplain {
+					row = append(row, rj.Explanation)
+				}
+				if csvSameAs {
+					row = append(row, strings.Join(rj.SameAs, ";"))
+				}
+				rows = append(rows, row)
+			}
+		}
+		return rows, objs, nil
 	}
 
 	// collect records
-	rows := [][]string{{"Name", "Type", "TTL", "Values"}}
-	if err := svc.ListResourceRecordSetsPages(&route53.ListResourceRecordSetsInput{
+	w := newListWriter(header, opts.stream)
+	var sets []*route53.ResourceRecordSet
+	var explanations []string
+	var renderErr error
+	var fetched int
+	var truncated bool
+	// filters apply after fetching, so only an unfiltered --limit can
+	// shrink the pages
+	fetchLimit := opts.limit
+	if opts.nameFilter != "" || opts.typeFilter != "" || opts.valueFilter != "" {
+		fetchLimit = 0
+	}
+	if err := svc.ListResourceRecordSetsPagesWithContext(ctx, &route53.ListResourceRecordSetsInput{
 		HostedZoneId: aws.String(zoneID),
+		MaxItems:     maxItems(fetchLimit),
 	}, func(out *route53.ListResourceRecordSetsOutput, last bool) bool {
-		for _, rr := range out.ResourceRecordSets {
-			vals := make([]string, len(rr.ResourceRecords))
-			for i, r := range rr.ResourceRecords {
-				vals[i] = aws.StringValue(r.Value)
-			}
-			rows = append(rows, []string{
-				aws.StringValue(rr.Name),
-				aws.StringValue(rr.Type),
-				fmt.Sprintf("%d", aws.Int64Value(rr.TTL)),
-				strings.Join(vals, ", "),
-			})
+		page := filterRecordSets(out.ResourceRecordSets, aws.StringValue(zone.Name), opts)
+		page, truncated = limitPage(page, fetched, opts.limit, last)
+		fetched += len(page)
+		if !opts.stream {
+			sets = append(sets, page...)
+			return !last && !truncated
 		}
-		return !last
+		if tableExplain {
+			explanations = append(explanations, explainAll(page)...)
+		}
+		rows, objs, err := render(page)
+		if err == nil {
+			err = w.addPage(rows, objs)
+		}
+		if err != nil {
+			renderErr = err
+			return false
+		}
+		return !last && !truncated
 	}); err != nil {
 		return err
 	}
+	if renderErr != nil {
+		return renderErr
+	}
 
-	// align & print
-	widths := make([]int, len(rows[0]))
-	for _, r := range rows {
-		for i, c := range r {
-			if len(c) > widths[i] {
-				widths[i] = len(c)
-			}
+	if !opts.stream {
+		sortRecordSets(sets, opts.sortKey, opts.reverse)
+		if tableExplain {
+			explanations = explainAll(sets)
+		}
+		if len(opts.collapseLabels) > 0 {
+			sets, collapsed = collapseApex(sets, aws.StringValue(zone.Name), opts.collapseLabels)
+		}
+		rows, objs, err := render(sets)
+		if err != nil {
+			return err
+		}
+		if err := w.addPage(rows, objs); err != nil {
+			return err
 		}
 	}
-	for ri, r := range rows {
-		for i, c := range r {
-			cell := c
-			if ri == 0 {
-				cell = strings.ToUpper(c)
-			}
-			fmt.Printf("%-*s  ", widths[i], cell)
+	if err := w.close(); err != nil {
+		return err
+	}
+	if w.n == 0 {
+		if strict {
+			return errEmptyResult
+		}
+		if !quiet {
+			fmt.Fprintf(os.Stderr, "no records found in %s\n", aws.StringValue(zone.Name))
 		}
+	}
+	if truncated && !quiet {
+		if opts.nameFilter == "" && opts.typeFilter == "" && opts.valueFilter == "" && zone.ResourceRecordSetCount != nil {
+			printFooter("... (showing %d of %d)", fetched, aws.Int64Value(zone.ResourceRecordSetCount))
+		} else {
+			printFooter("... (showing first %d matches)", fetched)
+		}
+	}
+	if len(explanations) > 0 {
 		fmt.Println()
+		for _, e := range explanations {
+			fmt.Println(e)
+		}
 	}
 	return nil
 }
 
-// zoneInfo prints either the ID/name or count for one zone
-func zoneInfo(cfg *config, identifier string, countOnly bool) error {
-	sess, err := session.NewSession(&aws.Config{
-		Region:      aws.String(cfg.Region),
-		Credentials: credentials.NewStaticCredentials(cfg.AccessKey, cfg.SecretKey, ""),
+// healthStatuses resolves the health checks referenced by sets concurrently,
+// mapping each health check ID to OK or FAIL. A check that cannot be read
+// (deleted, or not permitted) maps to "unknown", with a warning on stderr,
+// so the listing still goes out.
+func healthStatuses(ctx context.Context, svc Route53API, sets []*route53.ResourceRecordSet) map[string]string {
+	seen := map[string]bool{}
+	var ids []string
+	for _, rr := range sets {
+		if id := aws.StringValue(rr.HealthCheckId); id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	statuses := make([]string, len(ids))
+	errs := make([]error, len(ids))
+	parallel(len(ids), func(i int) {
+		statuses[i], errs[i] = healthStatus(ctx, svc, ids[i])
+	})
+
+	health := make(map[string]string, len(ids))
+	for i, id := range ids {
+		if errs[i] != nil {
+			statuses[i] = "unknown"
+			if !quiet {
+				fmt.Fprintf(os.Stderr, "warning: health check %s: %v\n", id, friendlyError(errs[i]))
+			}
+		}
+		health[id] = statuses[i]
+	}
+	return health
+}
+
+// healthStatus reports OK when more than 18% of Route53's checkers see the
+// endpoint as healthy, which is the threshold Route53 itself uses
+func healthStatus(ctx context.Context, svc Route53API, id string) (string, error) {
+	out, err := svc.GetHealthCheckStatusWithContext(ctx, &route53.GetHealthCheckStatusInput{
+		HealthCheckId: aws.String(id),
 	})
 	if err != nil {
-		return err
+		return "", err
+	}
+	var healthy int
+	for _, o := range out.HealthCheckObservations {
+		if o.StatusReport != nil && strings.HasPrefix(aws.StringValue(o.StatusReport.Status), "Success") {
+			healthy++
+		}
+	}
+	if len(out.HealthCheckObservations) > 0 && float64(healthy)/float64(len(out.HealthCheckObservations)) > 0.18 {
+		return "OK", nil
 	}
-	svc := route53.New(sess)
+	return "FAIL", nil
+}
 
-	dom := identifier
-	isDomain := strings.Contains(identifier, ".")
-	if isDomain && !strings.HasSuffix(dom, ".") {
-		dom += "."
+// callerIdentity resolves the account ID & ARN the config authenticates as
+func callerIdentity(ctx context.Context, cfg *config) (string, string, error) {
+	sess, err := newSession(cfg)
+	if err != nil {
+		return "", "", err
+	}
+	out, err := stsClient(sess).GetCallerIdentityWithContext(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", "", err
 	}
+	return aws.StringValue(out.Account), aws.StringValue(out.Arn), nil
+}
 
-	outZones, err := svc.ListHostedZones(&route53.ListHostedZonesInput{})
+// zoneInfo prints either the ID/name or count for one zone; JSON and CSV
+// output always carry all three
+func zoneInfo(ctx context.Context, svc Route53API, identifier string, countOnly bool) error {
+	zone, isDomain, err := findZone(ctx, svc, identifier)
 	if err != nil {
 		return err
 	}
-	var foundID, foundName string
-	var recordCount int64
-	for _, z := range outZones.HostedZones {
-		idVal := aws.StringValue(z.Id)
-		nameVal := aws.StringValue(z.Name)
-		if (isDomain && nameVal == dom) ||
-			(!isDomain && (idVal == identifier || idVal == "/hostedzone/"+identifier)) {
-			foundID = idVal
-			foundName = nameVal
-			recordCount = aws.Int64Value(z.ResourceRecordSetCount)
-			break
+	// a zone from the cache has no record count
+	if zone.ResourceRecordSetCount == nil && (countOnly || outputFormat != outputTable) {
+		out, err := svc.GetHostedZoneWithContext(ctx, &route53.GetHostedZoneInput{Id: zone.Id})
+		if err != nil {
+			return err
 		}
+		zone = out.HostedZone
 	}
-	if foundID == "" {
-		return fmt.Errorf("no hosted zone found for %q", identifier)
+
+	switch outputFormat {
+	case outputJSON:
+		return printJSON(newZoneJSON(zone, aws.Int64Value(zone.ResourceRecordSetCount)))
+	case outputCSV:
+		w := newListWriter([]string{"ID", "Name", "Records"}, false)
+		if err := w.addPage([][]string{{
+			strings.TrimPrefix(aws.StringValue(zone.Id), "/hostedzone/"),
+			aws.StringValue(zone.Name),
+			fmt.Sprintf("%d", aws.Int64Value(zone.ResourceRecordSetCount)),
+		}}, nil); err != nil {
+			return err
+		}
+		return w.close()
 	}
 
 	if countOnly {
-		fmt.Println(recordCount)
+		fmt.Println(aws.Int64Value(zone.ResourceRecordSetCount))
 	} else if isDomain {
-		fmt.Println(strings.TrimPrefix(foundID, "/hostedzone/"))
+		fmt.Println(strings.TrimPrefix(aws.StringValue(zone.Id), "/hostedzone/"))
 	} else {
-		fmt.Println(strings.TrimSuffix(foundName, "."))
+		fmt.Println(strings.TrimSuffix(aws.StringValue(zone.Name), "."))
+	}
+	return nil
+}
+
+// zoneNameServers prints the name servers of a zone's apex NS record set,
+// one per line, or as a JSON array
+func zoneNameServers(ctx context.Context, svc Route53API, identifier string) error {
+	zone, _, err := findZone(ctx, svc, identifier)
+	if err != nil {
+		return err
+	}
+	sets, err := lookupRecordSets(ctx, svc, aws.StringValue(zone.Id), aws.StringValue(zone.Name), route53.RRTypeNs)
+	if err != nil {
+		return err
+	}
+	servers := []string{}
+	for _, rr := range sets {
+		for _, r := range rr.ResourceRecords {
+			servers = append(servers, aws.StringValue(r.Value))
+		}
+	}
+	if outputFormat == outputJSON {
+		return printJSON(servers)
+	}
+	for _, ns := range servers {
+		fmt.Println(ns)
 	}
 	return nil
 }
@@ -283,14 +1233,53 @@ func main() {
 				fmt.Printf("r53q %s (commit %s, built %s)\n",
 					appVersion, gitCommit, buildDate)
 				// show config source
-				_, src, path, _ := loadConfigAndSource()
+				cfg, src, path, err := loadConfigAndSource()
+				switch {
+				case errors.Is(err, errNoConfig):
+					fmt.Println("Config: none (run r53q init)")
+				case err != nil:
+					fmt.Printf("Config: error (%v)\n", err)
+				}
 				switch src {
+				case "profile":
+					fmt.Println("Config: AWS shared config")
+					fmt.Printf("Credentials: AWS profile %q\n", cfg.Profile)
 				case "file":
 					fmt.Printf("Config: %s\n", path)
+					if cfg != nil && cfg.ConfigProfile != "" {
+						fmt.Printf("Config profile: %s\n", cfg.ConfigProfile)
+					}
+					fmt.Println("Credentials: static keys from config file")
 				case "env":
 					fmt.Println("Config: environment")
-				case "created":
-					fmt.Printf("Config: created at %s (please fill in credentials)\n", path)
+					fmt.Println("Credentials: static keys from environment")
+				}
+				if cfg != nil {
+					switch r := configuredRegion(cfg); {
+					case r != "":
+						fmt.Printf("Region: %s\n", r)
+					case src == "profile":
+						fmt.Printf("Region: from AWS profile (else %s)\n", defaultRegion)
+					default:
+						fmt.Printf("Region: %s (default)\n", defaultRegion)
+					}
+					if e := configuredEndpoint(); e != "" {
+						fmt.Printf("Endpoint: %s\n", e)
+					}
+				}
+				if assumeRoleARN != "" {
+					fmt.Printf("Assumed role: %s\n", assumeRoleARN)
+				}
+				// optionally resolve who those credentials belong to
+				if showIdentity && cfg != nil {
+					ctx, cancel := commandContext()
+					account, arn, err := callerIdentity(ctx, cfg)
+					cancel()
+					if err != nil {
+						fmt.Printf("Identity: unavailable (%v)\n", friendlyError(err))
+					} else {
+						fmt.Printf("Identity: account %s (%s)\n", account, arn)
+					}
 				}
 				os.Exit(0)
 			}
@@ -298,72 +1287,517 @@ func main() {
 		},
 	}
 
+	root.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+		if err := validateOutput(); err != nil {
+			fatal("invalid flags", err)
+		}
+		if err := validateColor(); err != nil {
+			fatal("invalid flags", err)
+		}
+		if pageSize < 0 {
+			fatal("invalid flags", invalid(errors.New("--page-size must not be negative")))
+		}
+	}
+
 	// global version flag
 	root.Flags().BoolVarP(&showVersion, "version", "v", false, "Show version & config path, then exit")
+	root.Flags().BoolVar(&showIdentity, "identity", false, "With --version, also resolve the AWS account ID (makes an STS call)")
+	root.PersistentFlags().StringVar(&configPath, "config", "", "Load exactly this config file, skipping the usual search")
+	root.PersistentFlags().StringVar(&configProfile, "config-profile", "", "Profile to use from an r53q.json that holds several (default: its \"default\" key)")
+	root.PersistentFlags().StringVar(&awsProfile, "profile", "", "Use ~/.config/r53q/<name>.json, or else the AWS named profile <name>; AWS_PROFILE applies only when no other credentials are found")
+	root.PersistentFlags().StringVar(&regionFlag, "region", "", "AWS region, overriding the config and AWS_REGION (default us-east-1)")
+	root.PersistentFlags().StringVar(&endpointURL, "endpoint-url", "", "Send Route53 calls to this URL instead of AWS, e.g. http://localhost:4566 (default $AWS_ENDPOINT_URL)")
+	root.PersistentFlags().BoolVar(&noVerifySSL, "no-verify-ssl", false, "Do not verify TLS certificates (self-signed --endpoint-url)")
+	root.PersistentFlags().StringVar(&assumeRoleARN, "assume-role-arn", "", "Assume this IAM role on top of the configured credentials")
+	root.PersistentFlags().StringVar(&externalID, "external-id", "", "External ID for --assume-role-arn")
+	root.PersistentFlags().StringVar(&roleSessionName, "role-session-name", "r53q", "Session name for --assume-role-arn")
+	root.PersistentFlags().BoolVar(&stsRegional, "sts-regional", false, "Call STS at the region's endpoint, sts.<region>.amazonaws.com (the default)")
+	root.PersistentFlags().BoolVar(&stsGlobal, "sts-global", false, "Call STS at the global endpoint, sts.amazonaws.com")
+	root.MarkFlagsMutuallyExclusive("sts-regional", "sts-global")
+	root.PersistentFlags().BoolVar(&noAutocreate, "no-autocreate", false, "No effect; config files are only written by r53q init")
+	root.PersistentFlags().MarkDeprecated("no-autocreate", "r53q no longer creates a config file on its own")
+	root.PersistentFlags().StringVar(&envFile, "env-file", "", "Load AWS credentials and region from this dotenv file")
+	root.PersistentFlags().CountVarP(&verbose, "verbose", "V", "Log API calls, timing and zone resolution to stderr; -VV adds full request/response dumps")
+	root.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Suppress informational messages on stderr and table/CSV headers")
+	root.PersistentFlags().StringVarP(&outputFormat, "output", "o", outputTable, "Output format: table, json or csv")
+	root.PersistentFlags().StringVar(&colorFlag, "color", colorAuto, "Color tables: auto (terminal without NO_COLOR), always or never")
+	root.PersistentFlags().BoolVar(&wide, "wide", false, "Add each command's extra columns (see README)")
+	root.PersistentFlags().DurationVar(&timeout, "timeout", 0, "Overall deadline for the command, e.g. 2m (0 = none)")
+	root.PersistentFlags().DurationVar(&requestTimeout, "request-timeout", 0, "Deadline for each individual API request, e.g. 10s (0 = none)")
+	root.PersistentFlags().BoolVar(&wait, "wait", false, "After a change, block until Route53 reports it INSYNC")
+	root.PersistentFlags().DurationVar(&waitTimeout, "wait-timeout", 2*time.Minute, "Give up on --wait after this long, exiting non-zero")
+	root.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Print mutating requests as JSON instead of sending them (lookups still run)")
+	root.PersistentFlags().StringVar(&changeCommentFlag, "comment", "", "Change batch comment for mutating commands (default \"r53q <command> by <identity>\")")
+	root.PersistentFlags().IntVar(&maxRetries, "max-retries", maxRetries, "Retries for throttled or failed API requests, with exponential backoff")
+	root.PersistentFlags().IntVar(&pageSize, "page-size", 0, "Items per list API call (MaxItems; 0 = service default)")
+	root.PersistentFlags().MarkHidden("page-size")
+	root.PersistentFlags().DurationVar(&cacheTTL, "cache-ttl", cacheTTL, "How long the cached zone list is trusted (0 disables the cache)")
+	root.PersistentFlags().BoolVar(&noCache, "no-cache", false, "Ignore the cached zone list and ask the API")
+	root.PersistentFlags().StringVar(&cacheDirFlag, "cache-dir", "", "Cache directory (default $XDG_CACHE_HOME/r53q or ~/.cache/r53q)")
 
 	// list/zones
 	list := &cobra.Command{Use: "list", Short: "List Route53 resources"}
+	list.PersistentFlags().BoolVar(&strict, "strict", false, "Exit non-zero when the listing has no rows")
+	var zoneOpts zonesOptions
 	zones := &cobra.Command{
 		Use:   "zones",
 		Short: "List hosted Route53 zones",
 		Run: func(cmd *cobra.Command, args []string) {
-			cfg, src, path, err := loadConfigAndSource()
-			if err != nil {
-				log.Fatalf("config error: %v", err)
-			}
-			if src == "created" {
-				log.Fatalf("No config found; created %s with empty values. Please populate credentials.", path)
-			}
-			if err := listZones(cfg); err != nil {
-				log.Fatalf("list zones failed: %v", err)
+			svc := requireClient(requireConfig())
+			ctx, cancel := commandContext()
+			defer cancel()
+			if err := listZones(ctx, svc, zoneOpts); err != nil {
+				fatal("list zones failed", err)
 			}
 		},
 	}
+	zones.Flags().IntVar(&zoneOpts.limit, "limit", 0, "Show at most this many zones (0 = all)")
+	zones.Flags().BoolVar(&zoneOpts.total, "total", false, "Also print the number of record sets across all zones (only that with --quiet)")
+	zones.Flags().BoolVar(&zoneOpts.liveCounts, "live-counts", false, "Count records per zone via the API instead of trusting the cached count")
+	zones.Flags().BoolVar(&zoneOpts.nameOnly, "name-only", false, "Print only the domain names, one per line")
+	zones.Flags().BoolVar(&zoneOpts.idOnly, "id-only", false, "Print only the zone IDs, one per line")
+	zones.Flags().BoolVar(&zoneOpts.private, "private", false, "Only private zones")
+	zones.Flags().BoolVar(&zoneOpts.public, "public", false, "Only public zones")
+	zones.Flags().BoolVar(&zoneOpts.withNS, "with-ns", false, "Add each public zone's name servers (one extra API call per zone)")
+	zones.MarkFlagsMutuallyExclusive("name-only", "id-only")
+	zones.MarkFlagsMutuallyExclusive("private", "public")
+	for _, f := range []string{"total", "live-counts", "with-ns"} {
+		zones.MarkFlagsMutuallyExclusive("name-only", f)
+		zones.MarkFlagsMutuallyExclusive("id-only", f)
+	}
 	list.AddCommand(zones)
 
 	// list records
+	var recOpts recordsOptions
+	var collapseApexFlag bool
+	var collapseLabels []string
 	records := &cobra.Command{
-		Use:   "records <zone-id|domain>",
-		Short: "List all records in a hosted zone",
-		Args:  cobra.ExactArgs(1),
+		Use:               "records <zone-id|domain>",
+		Short:             "List all records in a hosted zone",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeZones,
 		Run: func(cmd *cobra.Command, args []string) {
-			cfg, src, path, err := loadConfigAndSource()
-			if err != nil {
-				log.Fatalf("config error: %v", err)
+			if collapseApexFlag {
+				if recOpts.stream {
+					fatal("list records failed", invalid(errors.New("--collapse-apex needs the whole zone and cannot be combined with --stream")))
+				}
+				recOpts.collapseLabels = collapseLabels
 			}
-			if src == "created" {
-				log.Fatalf("No config found; created %s with empty values. Please populate credentials.", path)
+			if recOpts.stream && (cmd.Flags().Changed("sort") || recOpts.reverse) {
+				fatal("list records failed", invalid(errors.New("--sort and --reverse need the whole zone and cannot be combined with --stream")))
 			}
-			if err := listRecords(cfg, args[0]); err != nil {
-				log.Fatalf("list records failed: %v", err)
+			svc := requireClient(requireConfig())
+			ctx, cancel := commandContext()
+			defer cancel()
+			if err := listRecords(ctx, svc, args[0], recOpts); err != nil {
+				fatal("list records failed", err)
 			}
 		},
 	}
+	records.Flags().BoolVar(&recOpts.withHealth, "with-health", false, "Add a Health column for records backed by health checks (extra API calls)")
+	records.Flags().BoolVar(&recOpts.explain, "explain", false, "Describe each routing-policy record set in plain English")
+	records.Flags().StringVar(&recOpts.nameFilter, "name", "", "Only show record sets whose name contains this substring or matches this glob (case-insensitive)")
+	records.Flags().StringVar(&recOpts.typeFilter, "type", "", "Only show record sets of this type")
+	records.Flags().StringVar(&recOpts.valueFilter, "value-filter", "", "Only show record sets with a value containing this substring (case-insensitive)")
+	records.Flags().BoolVar(&recOpts.explode, "explode", false, "One row per value instead of joining a record set's values")
+	records.Flags().BoolVar(&humanTTL, "human", false, "Show TTLs as durations (1d, 5m, 1h30m) in table output")
+	records.Flags().BoolVar(&recOpts.splitPriority, "split-priority", false, "Show MX/SRV priority, weight and port in their own columns")
+	records.Flags().BoolVar(&collapseApexFlag, "collapse-apex", false, "Fold www (see --collapse-labels) into the apex row when their record sets are identical")
+	records.Flags().StringSliceVar(&collapseLabels, "collapse-labels", []string{"www"}, "Labels compared against the apex by --collapse-apex")
+	records.Flags().IntVar(&recOpts.limit, "limit", 0, "Show at most this many record sets, in API order (0 = all)")
+	records.Flags().StringVar(&recOpts.sortKey, "sort", sortName, "Sort by name, type or ttl (ties broken by name, then type)")
+	records.Flags().BoolVar(&recOpts.reverse, "reverse", false, "Reverse the sort order")
+	records.Flags().BoolVar(&recOpts.stream, "stream", false, "Print rows as pages arrive (bounded memory, approximate alignment)")
 	list.AddCommand(records)
 
 	// zone info
 	zone := &cobra.Command{
-		Use:   "zone <zone-id|domain> [count]",
-		Short: "Return a zone’s ID/name (default) or record count",
-		Args:  cobra.RangeArgs(1, 2),
+		Use:               "zone <zone-id|domain> [count|ns]",
+		Short:             "Return a zone’s ID/name (default), record count or name servers",
+		Args:              cobra.RangeArgs(1, 2),
+		ValidArgsFunction: completeZones,
+		Run: func(cmd *cobra.Command, args []string) {
+			svc := requireClient(requireConfig())
+			var what string
+			if len(args) == 2 {
+				what = strings.ToLower(args[1])
+			}
+			ctx, cancel := commandContext()
+			defer cancel()
+			var err error
+			switch what {
+			case "", "count":
+				err = zoneInfo(ctx, svc, args[0], what == "count")
+			case "ns":
+				err = zoneNameServers(ctx, svc, args[0])
+			default:
+				fatal("zone info failed", invalid(fmt.Errorf("unknown zone query %q (want count or ns)", args[1])))
+			}
+			if err != nil {
+				fatal("zone info failed", err)
+			}
+		},
+	}
+
+	// zone rename
+	var deleteOld, renameYes bool
+	rename := &cobra.Command{
+		Use:               "rename <old-zone-id|domain> <new-domain>",
+		Short:             "Copy a zone to a new domain, optionally deleting the old one",
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeZones,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg := requireConfig()
+			svc := requireClient(cfg)
+			ctx, cancel := commandContext()
+			defer cancel()
+			comment := changeComment(cmd)
+			if err := renameZone(ctx, svc, args[0], args[1], comment, deleteOld, renameYes); err != nil {
+				fatal("zone rename failed", err)
+			}
+		},
+	}
+	rename.Flags().BoolVar(&deleteOld, "delete-old", false, "Empty and delete the old zone once the copy succeeded")
+	rename.Flags().BoolVarP(&renameYes, "yes", "y", false, "Do not ask before deleting the old zone")
+	zone.AddCommand(rename)
+
+	// get record
+	get := &cobra.Command{Use: "get", Short: "Print Route53 values for scripts"}
+	var getName, getType string
+	var getFirst bool
+	getRec := &cobra.Command{
+		Use:               "record <zone-id|domain>",
+		Short:             "Print a record set's values, one per line",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeZones,
+		Run: func(cmd *cobra.Command, args []string) {
+			svc := requireClient(requireConfig())
+			ctx, cancel := commandContext()
+			defer cancel()
+			if err := getRecord(ctx, svc, args[0], getName, getType, getFirst); err != nil {
+				fatal("get record failed", err)
+			}
+		},
+	}
+	getRec.Flags().StringVar(&getName, "name", "", "Record name, relative to the zone (\"@\" for the apex) or absolute")
+	getRec.Flags().StringVar(&getType, "type", "", "Record type (A, AAAA, CNAME, TXT, ...)")
+	getRec.Flags().BoolVar(&getFirst, "first", false, "Print only the first value")
+	getRec.MarkFlagRequired("type")
+	get.AddCommand(getRec)
+
+	// create/upsert record
+	recordFlags := func(c *cobra.Command, spec *recordSpec) {
+		c.Flags().StringVar(&spec.name, "name", "", "Record name, relative to the zone (\"@\" for the apex) or absolute")
+		c.Flags().StringVar(&spec.typ, "type", "", "Record type (A, AAAA, CNAME, TXT, ...)")
+		c.Flags().StringArrayVar(&spec.values, "value", nil, "Record value; repeat for multi-value record sets")
+		c.Flags().Int64Var(&spec.ttl, "ttl", 300, "TTL in seconds")
+		c.Flags().StringVar(&spec.aliasTarget, "alias-target", "", "Create an alias to this DNS name (load balancer, CloudFront, ...) instead of values")
+		c.Flags().StringVar(&spec.aliasZoneID, "alias-hosted-zone-id", "", "Hosted zone ID of the --alias-target")
+		c.Flags().StringVar(&spec.aliasService, "alias-service", "", "Fill in --alias-hosted-zone-id for a --alias-target of this service: "+strings.Join(aliasServices, ", "))
+		c.Flags().BoolVar(&spec.evaluateHealth, "evaluate-target-health", false, "Let the alias inherit the health of its target")
+		c.MarkFlagRequired("type")
+		c.MarkFlagsMutuallyExclusive("alias-service", "alias-hosted-zone-id")
+		c.MarkFlagsMutuallyExclusive("alias-target", "value")
+		c.MarkFlagsMutuallyExclusive("alias-target", "ttl")
+	}
+	create := &cobra.Command{Use: "create", Short: "Create Route53 resources"}
+	var spec recordSpec
+	createRec := &cobra.Command{
+		Use:               "record <zone-id|domain>",
+		Short:             "Create a record set in a hosted zone; fails if it already exists",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeZones,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg := requireConfig()
+			svc := requireClient(cfg)
+			ctx, cancel := commandContext()
+			defer cancel()
+			comment := changeComment(cmd)
+			if err := writeRecord(ctx, svc, args[0], spec, comment, false); err != nil {
+				fatal("create record failed", err)
+			}
+		},
+	}
+	recordFlags(createRec, &spec)
+	create.AddCommand(createRec)
+	upsert := &cobra.Command{Use: "upsert", Short: "Create or replace Route53 resources"}
+	var upsertSpec recordSpec
+	upsertRec := &cobra.Command{
+		Use:               "record <zone-id|domain>",
+		Short:             "Create or replace a record set in a hosted zone",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeZones,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg := requireConfig()
+			svc := requireClient(cfg)
+			ctx, cancel := commandContext()
+			defer cancel()
+			comment := changeComment(cmd)
+			if err := writeRecord(ctx, svc, args[0], upsertSpec, comment, true); err != nil {
+				fatal("upsert record failed", err)
+			}
+		},
+	}
+	recordFlags(upsertRec, &upsertSpec)
+	upsert.AddCommand(upsertRec)
+	createZoneCmd := &cobra.Command{
+		Use:   "zone <domain>",
+		Short: "Create a public hosted zone and print its ID and name servers",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			svc := requireClient(requireConfig())
+			ctx, cancel := commandContext()
+			defer cancel()
+			if err := createZone(ctx, svc, args[0]); err != nil {
+				fatal("create zone failed", err)
+			}
+		},
+	}
+	create.AddCommand(createZoneCmd)
+
+	// delete record
+	deleteCmd := &cobra.Command{Use: "delete", Short: "Delete Route53 resources"}
+	var delName, delType, delSetID string
+	var delYes bool
+	deleteRec := &cobra.Command{
+		Use:               "record <zone-id|domain>",
+		Short:             "Delete a record set from a hosted zone",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeZones,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg := requireConfig()
+			svc := requireClient(cfg)
+			ctx, cancel := commandContext()
+			defer cancel()
+			comment := changeComment(cmd)
+			if err := deleteRecord(ctx, svc, args[0], delName, delType, delSetID, comment, delYes); err != nil {
+				fatal("delete record failed", err)
+			}
+		},
+	}
+	deleteRec.Flags().StringVar(&delName, "name", "", "Record name, relative to the zone (\"@\" for the apex) or absolute")
+	deleteRec.Flags().StringVar(&delType, "type", "", "Record type (A, AAAA, CNAME, TXT, ...)")
+	deleteRec.Flags().StringVar(&delSetID, "set-identifier", "", "Set identifier, for weighted/latency/failover/geo record sets")
+	deleteRec.Flags().BoolVarP(&delYes, "yes", "y", false, "Do not ask for confirmation")
+	deleteRec.MarkFlagRequired("type")
+	deleteCmd.AddCommand(deleteRec)
+	var delZoneForce, delZoneCascade, delZoneYes bool
+	deleteZoneCmd := &cobra.Command{
+		Use:               "zone <zone-id|domain>",
+		Short:             "Delete a hosted zone",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeZones,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg := requireConfig()
+			svc := requireClient(cfg)
+			ctx, cancel := commandContext()
+			defer cancel()
+			comment := changeComment(cmd)
+			if err := deleteZone(ctx, svc, args[0], comment, delZoneForce, delZoneCascade, delZoneYes); err != nil {
+				fatal("delete zone failed", err)
+			}
+		},
+	}
+	deleteZoneCmd.Flags().BoolVar(&delZoneForce, "force", false, "Also delete every record set besides the apex NS/SOA")
+	deleteZoneCmd.Flags().BoolVar(&delZoneCascade, "cascade-healthchecks", false, "Also delete health checks no record set outside this zone uses")
+	deleteZoneCmd.Flags().BoolVarP(&delZoneYes, "yes", "y", false, "Do not ask for confirmation")
+	deleteCmd.AddCommand(deleteZoneCmd)
+
+	// export
+	var exportFormat, exportFile string
+	var exportResume bool
+	export := &cobra.Command{
+		Use:               "export <zone-id|domain>",
+		Short:             "Export a hosted zone's records",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeZones,
 		Run: func(cmd *cobra.Command, args []string) {
-			cfg, src, path, err := loadConfigAndSource()
+			svc := requireClient(requireConfig())
+			ctx, cancel := commandContext()
+			defer cancel()
+			var err error
+			switch {
+			case exportFile != "":
+				err = exportZoneFile(ctx, svc, args[0], exportFormat, exportFile, exportResume)
+			case exportResume:
+				err = invalid(errors.New("--resume needs --output-file"))
+			default:
+				err = exportZone(ctx, svc, args[0], exportFormat, os.Stdout)
+			}
 			if err != nil {
-				log.Fatalf("config error: %v", err)
+				fatal("export failed", err)
 			}
-			if src == "created" {
-				log.Fatalf("No config found; created %s with empty values. Please populate credentials.", path)
+		},
+	}
+	export.Flags().StringVar(&exportFormat, "format", exportBIND, "Export format: bind (RFC 1035 zone file) or tfstate-import (terraform import commands)")
+	export.Flags().StringVar(&exportFile, "output-file", "", "Write to this file instead of stdout")
+	export.Flags().BoolVar(&exportResume, "resume", false, "Continue an interrupted --output-file export where it stopped")
+
+	// import
+	var importFile string
+	var importOverwriteApex, importSkipInvalid bool
+	importCmd := &cobra.Command{
+		Use:               "import <zone-id|domain>",
+		Short:             "Upsert the records of a BIND zone file into a hosted zone",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeZones,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg := requireConfig()
+			svc := requireClient(cfg)
+			ctx, cancel := commandContext()
+			defer cancel()
+			comment := changeComment(cmd)
+			if err := importZone(ctx, svc, args[0], importFile, comment, importOverwriteApex, importSkipInvalid); err != nil {
+				fatal("import failed", err)
 			}
-			countOnly := len(args) == 2 && strings.ToLower(args[1]) == "count"
-			if err := zoneInfo(cfg, args[0], countOnly); err != nil {
-				log.Fatalf("zone info failed: %v", err)
+		},
+	}
+	importCmd.Flags().StringVar(&importFile, "file", "", "Zone file to import")
+	importCmd.Flags().BoolVar(&importOverwriteApex, "overwrite-apex", false, "Also upsert the file's apex NS and SOA, replacing Route53's (changes the delegation)")
+	importCmd.Flags().BoolVar(&importSkipInvalid, "skip-invalid", false, "Warn about record sets over Route53's limits and import the rest")
+	importCmd.MarkFlagRequired("file")
+
+	// diff
+	var diffFile string
+	var diffManaged bool
+	diffCmd := &cobra.Command{
+		Use:               "diff <zone-id|domain>",
+		Short:             "Show how a zone differs from a zone file or JSON record list",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeZones,
+		Run: func(cmd *cobra.Command, args []string) {
+			svc := requireClient(requireConfig())
+			ctx, cancel := commandContext()
+			defer cancel()
+			if err := diffZone(ctx, svc, args[0], diffFile, diffManaged); err != nil {
+				fatal("diff failed", err)
 			}
 		},
 	}
+	diffCmd.Flags().StringVar(&diffFile, "file", "", "Desired state: a .json file like list records -o json output, or a BIND zone file")
+	diffCmd.Flags().BoolVar(&diffManaged, "include-managed", false, "Also compare the apex NS and SOA records Route53 manages")
+	diffCmd.MarkFlagRequired("file")
 
-	root.AddCommand(list, zone)
+	// apply
+	var applyFile string
+	var applyPrune, applyManaged, applyAtomic, applyYes bool
+	applyCmd := &cobra.Command{
+		Use:               "apply <zone-id|domain>",
+		Short:             "Change a zone to match a zone file or JSON record list",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeZones,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg := requireConfig()
+			svc := requireClient(cfg)
+			ctx, cancel := commandContext()
+			defer cancel()
+			comment := changeComment(cmd)
+			if err := applyZone(ctx, svc, args[0], applyFile, comment, applyPrune, applyManaged, applyAtomic, applyYes); err != nil {
+				fatal("apply failed", err)
+			}
+		},
+	}
+	applyCmd.Flags().StringVar(&applyFile, "file", "", "Desired state: a .json file like list records -o json output, or a BIND zone file")
+	applyCmd.Flags().BoolVar(&applyPrune, "prune", false, "Delete record sets that are not in the file")
+	applyCmd.Flags().BoolVar(&applyManaged, "include-managed", false, "Also reconcile the apex NS and SOA records Route53 manages")
+	applyCmd.Flags().BoolVar(&applyAtomic, "atomic", false, "Check every change first and send nothing if any would fail")
+	applyCmd.Flags().BoolVarP(&applyYes, "yes", "y", false, "Do not ask before deleting with --prune")
+	applyCmd.MarkFlagRequired("file")
+
+	// reverse lookup
+	whereCmd := &cobra.Command{
+		Use:   "where <ip|hostname>",
+		Short: "Find records in any zone that point at an IP or hostname",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			svc := requireClient(requireConfig())
+			ctx, cancel := commandContext()
+			defer cancel()
+			if err := where(ctx, svc, args[0]); err != nil {
+				fatal("where failed", err)
+			}
+		},
+	}
 
+	// search
+	var searchBy string
+	var searchWorkers int
+	searchCmd := &cobra.Command{
+		Use:   "search <query>",
+		Short: "Find records by name or value across all zones",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			svc := requireClient(requireConfig())
+			ctx, cancel := commandContext()
+			defer cancel()
+			if err := searchRecords(ctx, svc, args[0], searchBy, searchWorkers); err != nil {
+				fatal("search failed", err)
+			}
+		},
+	}
+	searchCmd.Flags().StringVar(&searchBy, "by", searchByName, "Match on name (contains the query) or value (equals the query)")
+	searchCmd.Flags().IntVar(&searchWorkers, "concurrency", apiWorkers, "Zones scanned at once; keep low to avoid throttling")
+
+	// cache management
+	cache := &cobra.Command{Use: "cache", Short: "Manage the local r53q cache"}
+	cacheClear := &cobra.Command{
+		Use:   "clear",
+		Short: "Remove the files r53q has cached",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			dir, n, err := clearCache()
+			if err != nil {
+				fatal("cache clear failed", err)
+			}
+			fmt.Printf("Removed %d cached files from %s\n", n, dir)
+		},
+	}
+	cache.AddCommand(cacheClear)
+
+	// config management
+	configCmd := &cobra.Command{Use: "config", Short: "Manage the r53q config file"}
+	var migrateYes bool
+	migrate := &cobra.Command{
+		Use:   "migrate",
+		Short: "Move r53q.json from the binary dir or cwd to ~/.config",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := migrateConfig(migrateYes); err != nil {
+				fatal("config migrate failed", err)
+			}
+		},
+	}
+	migrate.Flags().BoolVarP(&migrateYes, "yes", "y", false, "Do not ask for confirmation")
+	configCmd.AddCommand(migrate)
+
+	// init
+	var initForce bool
+	initCmd := &cobra.Command{
+		Use:   "init [path]",
+		Short: "Prompt for credentials and write a config file (default ~/.config/r53q.json)",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			path := ""
+			if len(args) == 1 {
+				path = args[0]
+			}
+			p, err := initConfig(path, initForce)
+			if err != nil {
+				fatal("init failed", err)
+			}
+			fmt.Printf("Wrote %s\n", p)
+		},
+	}
+	initCmd.Flags().BoolVar(&initForce, "force", false, "Overwrite an existing config file")
+
+	root.AddCommand(initCmd, list, zone, get, create, upsert, deleteCmd, export, importCmd, diffCmd, applyCmd, searchCmd, whereCmd, cache, configCmd)
+
+	// only argument and flag parsing errors come back from Execute; cobra
+	// has already printed them
 	if err := root.Execute(); err != nil {
-		os.Exit(1)
+		if outputFormat == outputJSON {
+			printJSON(newErrorJSON(err.Error(), invalid(err)))
+		}
+		os.Exit(exitInvalid)
 	}
 }
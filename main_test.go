@@ -0,0 +1,816 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+// mockRoute53 serves canned zones and record sets and records the change
+// batches it is sent; anything else panics through the nil Route53API
+type mockRoute53 struct {
+	Route53API
+	zones   []*route53.HostedZone
+	sets    map[string][]*route53.ResourceRecordSet // by zone ID
+	changes []*route53.ChangeResourceRecordSetsInput
+}
+
+func (m *mockRoute53) ListHostedZonesPagesWithContext(_ aws.Context, _ *route53.ListHostedZonesInput, fn func(*route53.ListHostedZonesOutput, bool) bool, _ ...request.Option) error {
+	fn(&route53.ListHostedZonesOutput{HostedZones: m.zones}, true)
+	return nil
+}
+
+// ListResourceRecordSetsPagesWithContext starts at StartRecordName and
+// StartRecordType when given, like the real listing
+func (m *mockRoute53) ListResourceRecordSetsPagesWithContext(_ aws.Context, in *route53.ListResourceRecordSetsInput, fn func(*route53.ListResourceRecordSetsOutput, bool) bool, _ ...request.Option) error {
+	sets := m.sets[aws.StringValue(in.HostedZoneId)]
+	if in.StartRecordName != nil {
+		i := 0
+		for i < len(sets) && !(equalNames(aws.StringValue(sets[i].Name), aws.StringValue(in.StartRecordName)) &&
+			aws.StringValue(sets[i].Type) == aws.StringValue(in.StartRecordType)) {
+			i++
+		}
+		sets = sets[i:]
+	}
+	fn(&route53.ListResourceRecordSetsOutput{ResourceRecordSets: sets}, true)
+	return nil
+}
+
+func (m *mockRoute53) ChangeResourceRecordSetsWithContext(_ aws.Context, in *route53.ChangeResourceRecordSetsInput, _ ...request.Option) (*route53.ChangeResourceRecordSetsOutput, error) {
+	m.changes = append(m.changes, in)
+	id := fmt.Sprintf("/change/C%d", len(m.changes))
+	return &route53.ChangeResourceRecordSetsOutput{ChangeInfo: &route53.ChangeInfo{Id: aws.String(id)}}, nil
+}
+
+// GetHostedZoneWithContext returns the zone, with two name servers unless
+// it is private
+func (m *mockRoute53) GetHostedZoneWithContext(_ aws.Context, in *route53.GetHostedZoneInput, _ ...request.Option) (*route53.GetHostedZoneOutput, error) {
+	for _, z := range m.zones {
+		if sameZoneID(aws.StringValue(z.Id), aws.StringValue(in.Id)) {
+			out := &route53.GetHostedZoneOutput{HostedZone: z}
+			if !aws.BoolValue(z.Config.PrivateZone) {
+				out.DelegationSet = &route53.DelegationSet{NameServers: aws.StringSlice([]string{"ns-1.awsdns-01.org", "ns-2.awsdns-02.com"})}
+			}
+			return out, nil
+		}
+	}
+	return nil, awserr.New("NoSuchHostedZone", "no such zone", nil)
+}
+
+func plainSet(name, typ string, ttl int64, values ...string) *route53.ResourceRecordSet {
+	rr := &route53.ResourceRecordSet{Name: aws.String(name), Type: aws.String(typ), TTL: aws.Int64(ttl)}
+	for _, v := range values {
+		rr.ResourceRecords = append(rr.ResourceRecords, &route53.ResourceRecord{Value: aws.String(v)})
+	}
+	return rr
+}
+
+// newMock returns a client holding ear.pm (Z1, three record sets) and
+// example.org (Z2, empty)
+func newMock() *mockRoute53 {
+	return &mockRoute53{
+		zones: []*route53.HostedZone{
+			{Id: aws.String("/hostedzone/Z1"), Name: aws.String("ear.pm."), ResourceRecordSetCount: aws.Int64(3),
+				Config: &route53.HostedZoneConfig{PrivateZone: aws.Bool(false), Comment: aws.String("main")}},
+			{Id: aws.String("/hostedzone/Z2"), Name: aws.String("example.org."), ResourceRecordSetCount: aws.Int64(2),
+				Config: &route53.HostedZoneConfig{PrivateZone: aws.Bool(true)}},
+		},
+		sets: map[string][]*route53.ResourceRecordSet{
+			"/hostedzone/Z1": {
+				plainSet("ear.pm.", "A", 300, "1.2.3.4", "1.2.3.5"),
+				plainSet("ear.pm.", "MX", 3600, "10 mx.ear.pm."),
+				plainSet("www.ear.pm.", "CNAME", 60, "ear.pm."),
+			},
+		},
+	}
+}
+
+// setupTest puts the globals that shape output back to their defaults for
+// the duration of t, with the zone cache and color off
+func setupTest(t *testing.T) {
+	t.Helper()
+	saved := []any{outputFormat, quiet, wide, colorFlag, noCache, zoneCacheKey, wait, strict}
+	t.Cleanup(func() {
+		outputFormat, quiet, wide = saved[0].(string), saved[1].(bool), saved[2].(bool)
+		colorFlag, noCache, zoneCacheKey = saved[3].(string), saved[4].(bool), saved[5].(string)
+		wait, strict = saved[6].(bool), saved[7].(bool)
+	})
+	outputFormat, quiet, wide = outputTable, false, false
+	colorFlag, noCache, zoneCacheKey = colorNever, true, ""
+	wait, strict = false, false
+}
+
+// captureStdout runs fn and returns what it printed on stdout
+func captureStdout(t *testing.T, fn func() error) (string, error) {
+	t.Helper()
+	return capture(t, &os.Stdout, fn)
+}
+
+// capture runs fn with *f redirected to a pipe and returns what fn wrote
+// to it
+func capture(t *testing.T, f **os.File, fn func() error) (string, error) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig := *f
+	*f = w
+	done := make(chan string)
+	go func() {
+		b, _ := io.ReadAll(r)
+		done <- string(b)
+	}()
+	ferr := fn()
+	w.Close()
+	*f = orig
+	return <-done, ferr
+}
+
+func TestListZones(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		wide   bool
+		opts   zonesOptions
+		want   string
+	}{
+		{"table", outputTable, false, zonesOptions{},
+			"ID  NAME          RECORDS  \nZ1  ear.pm.       3        \nZ2  example.org.  2        \n"},
+		{"wide", outputTable, true, zonesOptions{},
+			"ID  NAME          RECORDS  PRIVATE  COMMENT  \nZ1  ear.pm.       3        false    main     \nZ2  example.org.  2        true              \n"},
+		{"total", outputTable, false, zonesOptions{total: true},
+			"ID  NAME          RECORDS  \nZ1  ear.pm.       3        \nZ2  example.org.  2        \nTotal: 5 record sets in 2 zones\n"},
+		{"name only", outputTable, false, zonesOptions{nameOnly: true}, "ear.pm\nexample.org\n"},
+		{"id only", outputTable, false, zonesOptions{idOnly: true}, "Z1\nZ2\n"},
+		{"csv", outputCSV, false, zonesOptions{}, "ID,Name,Records\nZ1,ear.pm.,3\nZ2,example.org.,2\n"},
+		{"private only", outputTable, false, zonesOptions{private: true}, "ID  NAME          RECORDS  \nZ2  example.org.  2        \n"},
+		{"public only", outputTable, false, zonesOptions{public: true, limit: 1}, "ID  NAME     RECORDS  \nZ1  ear.pm.  3        \n"},
+		// private zones have no delegation set
+		{"with ns", outputCSV, false, zonesOptions{withNS: true},
+			"ID,Name,Records,Name Servers\nZ1,ear.pm.,3,ns-1.awsdns-01.org;ns-2.awsdns-02.com\nZ2,example.org.,2,\n"},
+		{"public with ns json", outputJSON, false, zonesOptions{public: true, withNS: true}, `[
+  {
+    "id": "Z1",
+    "name": "ear.pm.",
+    "recordCount": 3,
+    "nameServers": [
+      "ns-1.awsdns-01.org",
+      "ns-2.awsdns-02.com"
+    ]
+  }
+]
+`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTest(t)
+			outputFormat, wide = tt.format, tt.wide
+			got, err := captureStdout(t, func() error {
+				return listZones(context.Background(), newMock(), tt.opts)
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListZonesLiveCountsWarning(t *testing.T) {
+	for _, q := range []bool{false, true} {
+		setupTest(t)
+		quiet = q
+		var stdout string
+		stderr, err := capture(t, &os.Stderr, func() (err error) {
+			stdout, err = captureStdout(t, func() error {
+				return listZones(context.Background(), newMock(), zonesOptions{liveCounts: true})
+			})
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if warned := strings.Contains(stderr, "--live-counts makes one extra API call"); warned == q {
+			t.Errorf("quiet=%v: stderr %q", q, stderr)
+		}
+		if !strings.Contains(stdout, "Z1  ear.pm.       3 ") || !strings.Contains(stdout, "Z2  example.org.  0 ") {
+			t.Errorf("quiet=%v: counts not live:\n%s", q, stdout)
+		}
+	}
+}
+
+func TestListRecords(t *testing.T) {
+	tests := []struct {
+		name   string
+		zone   string
+		format string
+		opts   recordsOptions
+		want   string
+	}{
+		{"by domain", "ear.pm", outputTable, recordsOptions{},
+			"NAME         TYPE   TTL   VALUES            \n" +
+				"ear.pm.      A      300   1.2.3.4, 1.2.3.5  \n" +
+				"ear.pm.      MX     3600  10 mx.ear.pm.     \n" +
+				"www.ear.pm.  CNAME  60    ear.pm.           \n"},
+		{"by id with type filter", "Z1", outputTable, recordsOptions{typeFilter: "mx"},
+			"NAME     TYPE  TTL   VALUES         \near.pm.  MX    3600  10 mx.ear.pm.  \n"},
+		{"explode", "ear.pm", outputTable, recordsOptions{typeFilter: "A", explode: true},
+			"NAME     TYPE  TTL  VALUES   \near.pm.  A     300  1.2.3.4  \near.pm.  A     300  1.2.3.5  \n"},
+		{"limit", "ear.pm", outputCSV, recordsOptions{limit: 1}, "Name,Type,TTL,Values\near.pm.,A,300,1.2.3.4;1.2.3.5\n"},
+		{"empty zone", "example.org", outputJSON, recordsOptions{}, "[]\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTest(t)
+			outputFormat = tt.format
+			tt.opts.sortKey = "name"
+			got, err := captureStdout(t, func() error {
+				return listRecords(context.Background(), newMock(), tt.zone, tt.opts)
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+// JSON consumers rely on ttl and recordCount being numbers, and on aliases
+// carrying an explicit "ttl": null rather than 0 or a missing key
+func TestJSONTypes(t *testing.T) {
+	setupTest(t)
+	outputFormat = outputJSON
+	out, err := captureStdout(t, func() error {
+		return listRecords(context.Background(), applyMock(), "ear.pm", recordsOptions{sortKey: "name"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []map[string]any
+	if err := json.Unmarshal([]byte(out), &records); err != nil {
+		t.Fatalf("%v in\n%s", err, out)
+	}
+	byName := map[string]map[string]any{}
+	for _, r := range records {
+		byName[r["name"].(string)+" "+r["type"].(string)] = r
+	}
+	if ttl, ok := byName["ear.pm. A"]["ttl"].(float64); !ok || ttl != 300 {
+		t.Errorf("plain ttl = %#v, want the number 300", byName["ear.pm. A"]["ttl"])
+	}
+	if ttl, ok := byName["cdn.ear.pm. A"]["ttl"]; !ok || ttl != nil {
+		t.Errorf("alias ttl = %#v (present %v), want null", ttl, ok)
+	}
+	if w, ok := byName["api.ear.pm. A"]["weight"].(float64); !ok || w != 10 {
+		t.Errorf("weight = %#v, want the number 10", byName["api.ear.pm. A"]["weight"])
+	}
+
+	out, err = captureStdout(t, func() error {
+		return listZones(context.Background(), newMock(), zonesOptions{})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var zones []map[string]any
+	if err := json.Unmarshal([]byte(out), &zones); err != nil {
+		t.Fatalf("%v in\n%s", err, out)
+	}
+	if len(zones) != 2 {
+		t.Fatalf("got %d zones, want 2", len(zones))
+	}
+	if n, ok := zones[0]["recordCount"].(float64); !ok || n != 3 {
+		t.Errorf("recordCount = %#v, want the number 3", zones[0]["recordCount"])
+	}
+}
+
+func TestWriteRecord(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    recordSpec
+		upsert  bool
+		action  string
+		wantErr string
+	}{
+		{"create new", recordSpec{name: "api", typ: "a", values: []string{"10.0.0.1"}, ttl: 60}, false, "CREATE", ""},
+		{"create existing", recordSpec{name: "www", typ: "CNAME", values: []string{"ear.pm"}, ttl: 60}, false, "", "already exists"},
+		{"upsert existing", recordSpec{name: "www", typ: "CNAME", values: []string{"ear.pm"}, ttl: 60}, true, "UPSERT", ""},
+		{"alias", recordSpec{name: "@", typ: "AAAA", aliasTarget: "d1.cloudfront.net", aliasZoneID: "Z2FDTNDATAQYW2"}, true, "UPSERT", ""},
+		{"bad value", recordSpec{name: "api", typ: "A", values: []string{"::1"}, ttl: 60}, false, "", "not an IPv4 address"},
+		{"no values", recordSpec{name: "api", typ: "A", ttl: 60}, false, "", "at least one --value"},
+		{"alias service", recordSpec{name: "cdn", typ: "A", aliasTarget: "d1.cloudfront.net", aliasService: "cloudfront"}, false, "CREATE", ""},
+		{"alias without zone", recordSpec{name: "cdn", typ: "A", aliasTarget: "d1.cloudfront.net"}, false, "", "needs --alias-hosted-zone-id or --alias-service"},
+		{"alias service without target", recordSpec{name: "cdn", typ: "A", values: []string{"1.2.3.4"}, ttl: 60, aliasService: "elb"}, false, "", "need --alias-target"},
+		{"alias service and zone", recordSpec{name: "cdn", typ: "A", aliasTarget: "d1.cloudfront.net", aliasService: "cloudfront", aliasZoneID: "Z1"}, false, "", "cannot be combined"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTest(t)
+			m := newMock()
+			out, err := captureStdout(t, func() error {
+				return writeRecord(context.Background(), m, "ear.pm", tt.spec, "test", tt.upsert)
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				if len(m.changes) != 0 {
+					t.Errorf("sent %d change batches after an error", len(m.changes))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(m.changes) != 1 || len(m.changes[0].ChangeBatch.Changes) != 1 {
+				t.Fatalf("want one batch of one change, got %v", m.changes)
+			}
+			in := m.changes[0]
+			c := in.ChangeBatch.Changes[0]
+			if got := aws.StringValue(c.Action); got != tt.action {
+				t.Errorf("action = %s, want %s", got, tt.action)
+			}
+			if got, want := aws.StringValue(c.ResourceRecordSet.Name), qualifyName(tt.spec.name, "ear.pm."); got != want {
+				t.Errorf("name = %s, want %s", got, want)
+			}
+			if aws.StringValue(in.HostedZoneId) != "/hostedzone/Z1" || aws.StringValue(in.ChangeBatch.Comment) != "test" {
+				t.Errorf("zone %s comment %q", aws.StringValue(in.HostedZoneId), aws.StringValue(in.ChangeBatch.Comment))
+			}
+			if out != "/change/C1\n" {
+				t.Errorf("printed %q", out)
+			}
+		})
+	}
+}
+
+func TestDeleteRecord(t *testing.T) {
+	tests := []struct {
+		name, record, typ string
+		wantErr           error
+	}{
+		{"existing", "www", "CNAME", nil},
+		{"apex", "@", "MX", nil},
+		{"missing", "nope", "A", errNoSuchRecord},
+		{"wrong type", "www", "A", errNoSuchRecord},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTest(t)
+			m := newMock()
+			_, err := captureStdout(t, func() error {
+				return deleteRecord(context.Background(), m, "ear.pm", tt.record, tt.typ, "", "test", true)
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if exitCode(err) != exitNotFound {
+					t.Errorf("exit code %d, want %d", exitCode(err), exitNotFound)
+				}
+				return
+			}
+			if len(m.changes) != 1 {
+				t.Fatalf("sent %d change batches, want 1", len(m.changes))
+			}
+			c := m.changes[0].ChangeBatch.Changes[0]
+			if aws.StringValue(c.Action) != "DELETE" || aws.StringValue(c.ResourceRecordSet.Type) != tt.typ {
+				t.Errorf("sent %s %s", aws.StringValue(c.Action), aws.StringValue(c.ResourceRecordSet.Type))
+			}
+			// a DELETE must repeat the live values exactly
+			if len(c.ResourceRecordSet.ResourceRecords) == 0 || c.ResourceRecordSet.TTL == nil {
+				t.Errorf("DELETE without the live values: %v", c.ResourceRecordSet)
+			}
+		})
+	}
+}
+
+func TestSubmitChanges(t *testing.T) {
+	// many returns n changes of one TXT value of size bytes each
+	many := func(n, size int, action string) []*route53.Change {
+		changes := make([]*route53.Change, n)
+		for i := range changes {
+			changes[i] = &route53.Change{
+				Action:            aws.String(action),
+				ResourceRecordSet: plainSet(fmt.Sprintf("h%d.ear.pm.", i), "TXT", 60, strings.Repeat("x", size)),
+			}
+		}
+		return changes
+	}
+	tests := []struct {
+		name    string
+		changes []*route53.Change
+		batches []int // changes per batch
+	}{
+		{"none", nil, nil},
+		{"one", many(1, 1, "CREATE"), []int{1}},
+		{"at the record limit", many(1000, 1, "CREATE"), []int{1000}},
+		{"over the record limit", many(1001, 1, "CREATE"), []int{1000, 1}},
+		{"upserts count twice", many(501, 1, "UPSERT"), []int{500, 1}},
+		{"character limit", many(5, 12000, "CREATE"), []int{2, 2, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMock()
+			ids, err := submitChanges(context.Background(), m, "/hostedzone/Z1", tt.changes, "test")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(ids) != len(tt.batches) || len(m.changes) != len(tt.batches) {
+				t.Fatalf("got %d IDs and %d batches, want %d", len(ids), len(m.changes), len(tt.batches))
+			}
+			var sent int
+			for i, in := range m.changes {
+				got := in.ChangeBatch.Changes
+				if len(got) != tt.batches[i] {
+					t.Errorf("batch %d has %d changes, want %d", i, len(got), tt.batches[i])
+				}
+				// order is kept across batches
+				if aws.StringValue(got[0].ResourceRecordSet.Name) != aws.StringValue(tt.changes[sent].ResourceRecordSet.Name) {
+					t.Errorf("batch %d starts with %s", i, aws.StringValue(got[0].ResourceRecordSet.Name))
+				}
+				sent += len(got)
+				if ids[i] != fmt.Sprintf("/change/C%d", i+1) {
+					t.Errorf("ID %d = %s", i, ids[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLoadconfigModeWarning(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no mode bits on Windows")
+	}
+	tests := []struct {
+		mode os.FileMode
+		warn bool
+	}{
+		{0600, false},
+		{0400, false},
+		{0644, true},
+		{0640, true},
+		{0604, true},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%04o", tt.mode), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "r53q.json")
+			if err := os.WriteFile(path, []byte(`{"access_key":"AKIA","secret_key":"s","region":"eu-west-1"}`), 0600); err != nil {
+				t.Fatal(err)
+			}
+			// set the mode explicitly, as the umask may have narrowed it
+			if err := os.Chmod(path, tt.mode); err != nil {
+				t.Fatal(err)
+			}
+			var cfg *config
+			stderr, err := capture(t, &os.Stderr, func() (err error) {
+				cfg, err = loadconfig(path)
+				return err
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.AccessKey != "AKIA" || cfg.Region != "eu-west-1" {
+				t.Errorf("loaded %+v", cfg)
+			}
+			want := ""
+			if tt.warn {
+				want = fmt.Sprintf("warning: %s has mode %04o; run chmod 600 on it\n", path, tt.mode)
+			}
+			if stderr != want {
+				t.Errorf("stderr = %q, want %q", stderr, want)
+			}
+		})
+	}
+}
+
+// throttleServer answers every Route53 call with a Throttling error until
+// throttles calls have been made, then with a hosted zone count of 7. It
+// counts the calls in *hits.
+func throttleServer(t *testing.T, throttles int, hits *int) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*hits++
+		w.Header().Set("Content-Type", "text/xml")
+		if *hits <= throttles {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `<?xml version="1.0"?>
+<ErrorResponse xmlns="https://route53.amazonaws.com/doc/2013-04-01/"><Error><Type>Sender</Type><Code>Throttling</Code><Message>Rate exceeded</Message></Error><RequestId>r1</RequestId></ErrorResponse>`)
+			return
+		}
+		fmt.Fprint(w, `<?xml version="1.0"?>
+<GetHostedZoneCountResponse xmlns="https://route53.amazonaws.com/doc/2013-04-01/"><HostedZoneCount>7</HostedZoneCount></GetHostedZoneCountResponse>`)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestThrottlingRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxRetries int
+		throttles  int
+		wantHits   int
+		wantErr    bool
+	}{
+		{"no retries", 0, 1, 1, true},
+		{"retried to success", 1, 1, 2, false},
+		{"retries exhausted", 1, 5, 2, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTest(t)
+			savedRetries, savedEndpoint := maxRetries, endpointURL
+			t.Cleanup(func() { maxRetries, endpointURL = savedRetries, savedEndpoint })
+			var hits int
+			maxRetries = tt.maxRetries
+			endpointURL = throttleServer(t, tt.throttles, &hits).URL
+			svc, err := newRoute53Client(&config{AccessKey: "AKIA", SecretKey: "s", Region: "us-east-1"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			out, err := svc.GetHostedZoneCountWithContext(context.Background(), &route53.GetHostedZoneCountInput{})
+			if hits != tt.wantHits {
+				t.Errorf("%d calls, want %d", hits, tt.wantHits)
+			}
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if aws.Int64Value(out.HostedZoneCount) != 7 {
+					t.Errorf("count = %d, want 7", aws.Int64Value(out.HostedZoneCount))
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("no error after the retries ran out")
+			}
+			if exitCode(err) != exitAWS {
+				t.Errorf("exit code %d, want %d", exitCode(err), exitAWS)
+			}
+			want := fmt.Sprintf("rate limited by Route53 even after %d retries; wait a moment, or raise --max-retries (Rate exceeded)", tt.maxRetries)
+			if got := friendlyError(err).Error(); got != want {
+				t.Errorf("friendly error %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+// healthMock fails GetHealthCheckStatus for the IDs in broken and reports
+// every other check healthy
+type healthMock struct {
+	Route53API
+	broken map[string]bool
+}
+
+func (m healthMock) GetHealthCheckStatusWithContext(_ aws.Context, in *route53.GetHealthCheckStatusInput, _ ...request.Option) (*route53.GetHealthCheckStatusOutput, error) {
+	if m.broken[aws.StringValue(in.HealthCheckId)] {
+		return nil, awserr.New("NoSuchHealthCheck", "gone", nil)
+	}
+	return &route53.GetHealthCheckStatusOutput{HealthCheckObservations: []*route53.HealthCheckObservation{
+		{StatusReport: &route53.StatusReport{Status: aws.String("Success: HTTP Status Code 200")}},
+	}}, nil
+}
+
+func TestHealthStatuses(t *testing.T) {
+	setupTest(t)
+	var sets []*route53.ResourceRecordSet
+	for _, id := range []string{"hc1", "hc2", "hc1", ""} {
+		rr := plainSet("ear.pm.", "A", 60, "1.2.3.4")
+		if id != "" {
+			rr.HealthCheckId = aws.String(id)
+		}
+		sets = append(sets, rr)
+	}
+	var health map[string]string
+	stderr, _ := capture(t, &os.Stderr, func() error {
+		health = healthStatuses(context.Background(), healthMock{broken: map[string]bool{"hc2": true}}, sets)
+		return nil
+	})
+	if want := map[string]string{"hc1": "OK", "hc2": "unknown"}; !maps.Equal(health, want) {
+		t.Errorf("got %v, want %v", health, want)
+	}
+	if !strings.Contains(stderr, "health check hc2") {
+		t.Errorf("no warning for hc2 in %q", stderr)
+	}
+}
+
+// createMock answers CreateHostedZone with zone Z9 and two name servers
+type createMock struct{ Route53API }
+
+func (createMock) CreateHostedZoneWithContext(_ aws.Context, in *route53.CreateHostedZoneInput, _ ...request.Option) (*route53.CreateHostedZoneOutput, error) {
+	return &route53.CreateHostedZoneOutput{
+		HostedZone:    &route53.HostedZone{Id: aws.String("/hostedzone/Z9"), Name: in.Name},
+		DelegationSet: &route53.DelegationSet{NameServers: aws.StringSlice([]string{"ns-1.awsdns-01.org", "ns-2.awsdns-02.com"})},
+		ChangeInfo:    &route53.ChangeInfo{Id: aws.String("/change/C1")},
+	}, nil
+}
+
+func TestCreateZoneOutput(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{outputTable, "Z9\nns-1.awsdns-01.org\nns-2.awsdns-02.com\n"},
+		{outputJSON, `{
+  "id": "Z9",
+  "name": "example.org.",
+  "nameServers": [
+    "ns-1.awsdns-01.org",
+    "ns-2.awsdns-02.com"
+  ]
+}
+`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			setupTest(t)
+			outputFormat = tt.format
+			got, err := captureStdout(t, func() error {
+				return createZone(context.Background(), createMock{}, "Example.org")
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigFilePick(t *testing.T) {
+	home := config{AccessKey: "AKIAHOME", Region: "eu-west-1"}
+	work := config{AccessKey: "AKIAWORK"}
+	tests := []struct {
+		name    string
+		file    configFile
+		pick    string
+		want    string // access key, then the profile name if any
+		wantErr string
+	}{
+		{"flat keys", configFile{config: config{AccessKey: "AKIAFLAT"}}, "", "AKIAFLAT", ""},
+		{"empty file", configFile{}, "", "", ""},
+		{"named", configFile{Profiles: map[string]config{"home": home, "work": work}}, "work", "AKIAWORK work", ""},
+		{"default", configFile{Profiles: map[string]config{"home": home, "work": work}, Default: "home"}, "", "AKIAHOME home", ""},
+		{"named beats default", configFile{Profiles: map[string]config{"home": home, "work": work}, Default: "home"}, "work", "AKIAWORK work", ""},
+		{"only profile", configFile{Profiles: map[string]config{"work": work}}, "", "AKIAWORK work", ""},
+		{"flat keys beside profiles", configFile{config: config{AccessKey: "AKIAFLAT"}, Profiles: map[string]config{"work": work}}, "", "AKIAFLAT", ""},
+		{"unknown profile", configFile{Profiles: map[string]config{"home": home, "work": work}}, "play", "",
+			`no config profile "play" (have home, work)`},
+		{"no profiles at all", configFile{config: config{AccessKey: "AKIAFLAT"}}, "play", "",
+			`no config profile "play": the file has no profiles`},
+		{"bad default", configFile{Profiles: map[string]config{"home": home}, Default: "gone"}, "", "",
+			`no config profile "gone" (have home)`},
+		{"ambiguous", configFile{Profiles: map[string]config{"home": home, "work": work}}, "", "",
+			"several config profiles and no default; pick one with --config-profile (home, work)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := tt.file.pick(tt.pick)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.TrimSpace(cfg.AccessKey + " " + cfg.ConfigProfile); got != tt.want {
+				t.Errorf("picked %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNameMatches(t *testing.T) {
+	tests := []struct {
+		name, pattern string
+		want          bool
+	}{
+		{"www.ear.pm.", "www", true},
+		{"www.ear.pm.", "WW", true},
+		{"www.ear.pm.", "mail", false},
+		{"www.ear.pm.", "www.ear.pm.", true},
+		// globs match the full name or the name relative to the zone
+		{"www.ear.pm.", "w*", true},
+		{"www.ear.pm.", "*.ear.pm", true},
+		{"api.dev.ear.pm.", "*.dev", true},
+		{"api.dev.ear.pm.", "api.*", true},
+		{"api.dev.ear.pm.", "a?i.dev", true},
+		{"api.dev.ear.pm.", "[ab]pi.*", true},
+		{"api.dev.ear.pm.", "*.prod", false},
+		{`\052.ear.pm.`, "*", true},
+		{`\052.ear.pm.`, "\\*", true},
+	}
+	for _, tt := range tests {
+		rr := plainSet(tt.name, "A", 60, "1.1.1.1")
+		if got := nameMatches(rr, "ear.pm.", tt.pattern); got != tt.want {
+			t.Errorf("nameMatches(%s, %q) = %t, want %t", tt.name, tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestFilterRecordSets(t *testing.T) {
+	sets := []*route53.ResourceRecordSet{
+		plainSet("ear.pm.", "A", 60, "192.0.2.1"),
+		plainSet("ear.pm.", "MX", 60, "10 MX.ear.pm."),
+		plainSet("www.ear.pm.", "A", 60, "192.0.2.2"),
+		aliasSet("cdn.ear.pm.", "A", "d1.CloudFront.net."),
+	}
+	tests := []struct {
+		name string
+		opts recordsOptions
+		want []string
+	}{
+		{"no filters", recordsOptions{}, []string{"ear.pm A ", "ear.pm MX ", "www.ear.pm A ", "cdn.ear.pm A "}},
+		{"type", recordsOptions{typeFilter: "MX"}, []string{"ear.pm MX "}},
+		{"name", recordsOptions{nameFilter: "w*"}, []string{"www.ear.pm A "}},
+		{"value", recordsOptions{valueFilter: "mx.EAR"}, []string{"ear.pm MX "}},
+		{"alias target value", recordsOptions{valueFilter: "cloudfront"}, []string{"cdn.ear.pm A "}},
+		{"combined", recordsOptions{typeFilter: "A", valueFilter: "192.0.2"}, []string{"ear.pm A ", "www.ear.pm A "}},
+		{"nothing", recordsOptions{typeFilter: "TXT"}, nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, rr := range filterRecordSets(sets, "ear.pm.", tt.opts) {
+			got = append(got, setKey(rr))
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFormatTTL(t *testing.T) {
+	setupTest(t)
+	saved := humanTTL
+	t.Cleanup(func() { humanTTL = saved })
+	tests := []struct {
+		human  bool
+		format string
+		ttl    int64
+		want   string
+	}{
+		{false, outputTable, 86400, "86400"},
+		{true, outputTable, 86400, "1d"},
+		{true, outputTable, 5400, "1h30m"},
+		{true, outputTable, 300, "5m"},
+		{true, outputTable, 90061, "1d1h1m1s"},
+		{true, outputTable, 45, "45s"},
+		{true, outputTable, 0, "0"},
+		// durations are for people; CSV and JSON keep seconds
+		{true, outputCSV, 5400, "5400"},
+		{true, outputJSON, 5400, "5400"},
+	}
+	for _, tt := range tests {
+		humanTTL, outputFormat = tt.human, tt.format
+		if got := formatTTL(tt.ttl); got != tt.want {
+			t.Errorf("formatTTL(%d) human=%v %s = %q, want %q", tt.ttl, tt.human, tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestSTSClientEndpoint(t *testing.T) {
+	saved := stsGlobal
+	t.Cleanup(func() { stsGlobal = saved })
+	sess, err := session.NewSession(&aws.Config{Region: aws.String("eu-west-1"),
+		Credentials: credentials.NewStaticCredentials("AKIA", "s", "")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		global bool
+		want   string
+	}{
+		{false, "https://sts.eu-west-1.amazonaws.com"},
+		{true, "https://sts.amazonaws.com"},
+	} {
+		stsGlobal = tt.global
+		if got := stsClient(sess).Endpoint; got != tt.want {
+			t.Errorf("global=%v: endpoint %s, want %s", tt.global, got, tt.want)
+		}
+	}
+}
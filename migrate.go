@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// legacyConfigPaths lists r53q.json locations that migrate consolidates:
+// next to the binary and the current directory, in search order
+func legacyConfigPaths() []string {
+	var paths []string
+	if exe, err := os.Executable(); err == nil {
+		paths = append(paths, filepath.Join(filepath.Dir(exe), "r53q.json"))
+	}
+	if cwd, err := os.Getwd(); err == nil {
+		p := filepath.Join(cwd, "r53q.json")
+		if len(paths) == 0 || paths[0] != p {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// migrateConfig moves the first legacy r53q.json it finds to
+// $HOME/.config/r53q.json with 0600 permissions. It never overwrites an
+// existing target and asks before moving unless assumeYes is set.
+func migrateConfig(assumeYes bool) error {
+	var src string
+	for _, p := range legacyConfigPaths() {
+		if _, err := os.Stat(p); err == nil {
+			src = p
+			break
+		}
+	}
+	if src == "" {
+		return errors.New("no r53q.json next to the binary or in the current directory")
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+	dst := filepath.Join(home, ".config", "r53q.json")
+	if _, err := os.Stat(dst); err == nil {
+		return fmt.Errorf("%s already exists, refusing to overwrite", dst)
+	}
+
+	if !assumeYes && !confirm(fmt.Sprintf("Move %s to %s?", src, dst)) {
+		return errors.New("aborted")
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+		return err
+	}
+	if err := moveFile(src, dst); err != nil {
+		return err
+	}
+	if err := os.Chmod(dst, 0600); err != nil {
+		return err
+	}
+	fmt.Printf("Moved %s to %s\n", src, dst)
+	return nil
+}
+
+// moveFile renames src to dst, falling back to copy & delete when they are
+// on different filesystems
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return os.Remove(src)
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+// output formats accepted by --output
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputCSV   = "csv"
+)
+
+// validateOutput checks the --output flag
+func validateOutput() error {
+	switch outputFormat {
+	case outputTable, outputJSON, outputCSV:
+		return nil
+	}
+	return invalid(fmt.Errorf("unknown output format %q (want table, json or csv)", outputFormat))
+}
+
+// listWriter renders a listing in the --output format. Tables are buffered
+// so columns line up, unless streaming, in which case widths are sampled
+// from the first page. JSON arrays and CSV are written as pages arrive.
+type listWriter struct {
+	header []string
+	stream bool
+	rows   [][]string
+	widths []int
+	styles []string
+	csv    *csv.Writer
+	n      int
+}
+
+// newListWriter starts a listing with the given table/CSV header
+func newListWriter(header []string, stream bool) *listWriter {
+	return &listWriter{header: header, stream: stream}
+}
+
+// addPage adds rows (table & CSV) and the matching objects (JSON)
+func (w *listWriter) addPage(rows [][]string, objs []any) error {
+	switch outputFormat {
+	case outputJSON:
+		for _, o := range objs {
+			b, err := json.MarshalIndent(o, "  ", "  ")
+			if err != nil {
+				return err
+			}
+			if w.n == 0 {
+				fmt.Print("[\n  ")
+			} else {
+				fmt.Print(",\n  ")
+			}
+			os.Stdout.Write(b)
+			w.n++
+		}
+	case outputCSV:
+		if w.csv == nil {
+			w.csv = csv.NewWriter(os.Stdout)
+			if !quiet {
+				w.csv.Write(w.header)
+			}
+		}
+		for _, r := range rows {
+			w.csv.Write(r)
+		}
+		w.n += len(rows)
+		w.csv.Flush()
+		return w.csv.Error()
+	default:
+		w.n += len(rows)
+		if !w.stream {
+			w.rows = append(w.rows, rows...)
+			return nil
+		}
+		if w.widths == nil {
+			header := w.header
+			if quiet {
+				header = nil
+			}
+			w.widths = columnWidths(append([][]string{header}, rows...))
+			w.styles = columnStyles(w.header)
+			if !quiet {
+				printRow(w.widths, w.styles, w.header, true)
+			}
+		}
+		for _, r := range rows {
+			printRow(w.widths, w.styles, r, false)
+		}
+	}
+	return nil
+}
+
+// close finishes the listing. An empty table prints nothing, an empty JSON
+// listing is "[]" and an empty CSV is just the header. --quiet drops the
+// table & CSV headers.
+func (w *listWriter) close() error {
+	switch outputFormat {
+	case outputJSON:
+		if w.n == 0 {
+			fmt.Println("[]")
+		} else {
+			fmt.Println("\n]")
+		}
+	case outputCSV:
+		if w.csv == nil {
+			return w.addPage(nil, nil)
+		}
+	default:
+		if !w.stream && len(w.rows) > 0 {
+			printTable(w.header, w.rows)
+		}
+	}
+	return nil
+}
+
+// printFooter writes a summary line after a listing: on stdout below a
+// table, on stderr for JSON & CSV so the data stays parseable
+func printFooter(format string, args ...any) {
+	out := os.Stdout
+	if outputFormat != outputTable {
+		out = os.Stderr
+	}
+	fmt.Fprintf(out, format+"\n", args...)
+}
+
+// limitPage trims a fetched page so that at most limit items are kept
+// overall, given have already kept (limit 0 = no limit). truncated
+// reports that items were left out, in this page or in pages not fetched.
+func limitPage[T any](page []T, have, limit int, last bool) (kept []T, truncated bool) {
+	if limit == 0 || have+len(page) < limit {
+		return page, false
+	}
+	kept = page[:limit-have]
+	return kept, len(kept) < len(page) || !last
+}
+
+// printJSON writes v as indented JSON
+func printJSON(v any) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(b))
+	return nil
+}
+
+// The JSON shapes below are documented in the README. Keys are camelCase
+// like the AWS API's; fields may be added but never renamed or retyped.
+
+// zoneJSON is the JSON shape of a hosted zone
+type zoneJSON struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	RecordCount int64    `json:"recordCount"`
+	Private     *bool    `json:"private,omitempty"`
+	Comment     string   `json:"comment,omitempty"`
+	NameServers []string `json:"nameServers,omitempty"`
+}
+
+// delegationJSON is the JSON shape of a newly created zone: what to give
+// the registrar
+type delegationJSON struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	NameServers []string `json:"nameServers"`
+}
+
+// aliasJSON is the JSON shape of an alias target
+type aliasJSON struct {
+	DNSName              string `json:"dnsName"`
+	HostedZoneID         string `json:"hostedZoneId"`
+	EvaluateTargetHealth bool   `json:"evaluateTargetHealth"`
+}
+
+// recordJSON is the JSON shape of a record set. TTL is null for aliases,
+// which have none; routing fields only appear on routing-policy sets.
+type recordJSON struct {
+	Name          string     `json:"name"`
+	Type          string     `json:"type"`
+	TTL           *int64     `json:"ttl"`
+	Values        []string   `json:"values"`
+	AliasTarget   *aliasJSON `json:"aliasTarget,omitempty"`
+	SetIdentifier string     `json:"setIdentifier,omitempty"`
+	Weight        *int64     `json:"weight,omitempty"`
+	Location      string     `json:"location,omitempty"`
+	Failover      string     `json:"failover,omitempty"`
+	HealthCheckID string     `json:"healthCheckId,omitempty"`
+	Health        string     `json:"health,omitempty"`
+	SameAs        []string   `json:"sameAs,omitempty"`
+	Explanation   string     `json:"explanation,omitempty"`
+}
+
+// errorJSON is the JSON shape of a failed command: the message logged on
+// stderr, the exit code and its category, and the AWS error code if any
+type errorJSON struct {
+	Error struct {
+		Message string `json:"message"`
+		Code    string `json:"code"`
+		Exit    int    `json:"exit"`
+		AWSCode string `json:"awsCode,omitempty"`
+	} `json:"error"`
+}
+
+// newZoneJSON converts a hosted zone, with its record count supplied
+// separately so live counts can be used
+func newZoneJSON(z *route53.HostedZone, count int64) zoneJSON {
+	zj := zoneJSON{
+		ID:          strings.TrimPrefix(aws.StringValue(z.Id), "/hostedzone/"),
+		Name:        aws.StringValue(z.Name),
+		RecordCount: count,
+	}
+	if wide && z.Config != nil {
+		zj.Private = z.Config.PrivateZone
+		zj.Comment = aws.StringValue(z.Config.Comment)
+	}
+	return zj
+}
+
+// newRecordJSON converts a record set
+func newRecordJSON(rr *route53.ResourceRecordSet) recordJSON {
+	rj := recordJSON{
+		Name:          aws.StringValue(rr.Name),
+		Type:          aws.StringValue(rr.Type),
+		TTL:           rr.TTL,
+		Values:        make([]string, len(rr.ResourceRecords)),
+		SetIdentifier: aws.StringValue(rr.SetIdentifier),
+		Weight:        rr.Weight,
+		Failover:      aws.StringValue(rr.Failover),
+		HealthCheckID: aws.StringValue(rr.HealthCheckId),
+	}
+	for i, r := range rr.ResourceRecords {
+		rj.Values[i] = aws.StringValue(r.Value)
+	}
+	if rr.AliasTarget != nil {
+		rj.TTL = nil
+		rj.AliasTarget = &aliasJSON{
+			DNSName:              aws.StringValue(rr.AliasTarget.DNSName),
+			HostedZoneID:         aws.StringValue(rr.AliasTarget.HostedZoneId),
+			EvaluateTargetHealth: aws.BoolValue(rr.AliasTarget.EvaluateTargetHealth),
+		}
+	}
+	switch {
+	case rr.Region != nil:
+		rj.Location = aws.StringValue(rr.Region)
+	case rr.GeoLocation != nil:
+		rj.Location = geoLabel(rr.GeoLocation)
+	}
+	return rj
+}
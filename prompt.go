@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// stdin is shared by every prompt so buffered input is not lost between them
+var stdin = bufio.NewReader(os.Stdin)
+
+// confirm asks a yes/no question on stderr and reads the answer from stdin;
+// anything but y/yes (including EOF) counts as no
+func confirm(question string) bool {
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", question)
+	line, _ := stdin.ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// ask prompts on stderr and reads one line from stdin; an empty answer
+// returns def
+func ask(question, def string) string {
+	if def != "" {
+		fmt.Fprintf(os.Stderr, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(os.Stderr, "%s: ", question)
+	}
+	line, _ := stdin.ReadString('\n')
+	if line = strings.TrimSpace(line); line != "" {
+		return line
+	}
+	return def
+}
+
+// askSecret is ask without echo when stdin is a terminal
+func askSecret(question string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return ask(question, ""), nil
+	}
+	fmt.Fprintf(os.Stderr, "%s: ", question)
+	b, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	return strings.TrimSpace(string(b)), err
+}
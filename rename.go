@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+// hostnameTypes hold domain names in their values, so those values are
+// rewritten along with the owner names on rename
+var hostnameTypes = map[string]bool{
+	route53.RRTypeCname: true,
+	route53.RRTypeMx:    true,
+	route53.RRTypeSrv:   true,
+	route53.RRTypePtr:   true,
+}
+
+// renameZone "renames" a zone the only way Route53 allows: it creates a zone
+// for newDomain, copies every record set except the apex NS/SOA (rewriting
+// names, hostname values and same-zone alias targets from the old suffix to
+// the new one), and prints the new delegation. With deleteOld it then
+// empties and deletes the old zone, after confirmation unless assumeYes.
+// Every change batch carries comment.
+func renameZone(ctx context.Context, svc Route53API, identifier, newDomain, comment string, deleteOld, assumeYes bool) error {
+	old, _, err := findZone(ctx, svc, identifier)
+	if err != nil {
+		return err
+	}
+	if old.Config == nil {
+		out, err := svc.GetHostedZoneWithContext(ctx, &route53.GetHostedZoneInput{Id: old.Id})
+		if err != nil {
+			return err
+		}
+		old = out.HostedZone
+	}
+	if old.Config != nil && aws.BoolValue(old.Config.PrivateZone) {
+		return errors.New("renaming private zones is not supported")
+	}
+	oldName := aws.StringValue(old.Name)
+	newName, err := normalizeDomain(newDomain)
+	if err != nil {
+		return err
+	}
+	if equalNames(oldName, newName) {
+		return errors.New("old and new domain are the same")
+	}
+
+	sets, err := zoneRecordSets(ctx, svc, aws.StringValue(old.Id))
+	if err != nil {
+		return err
+	}
+
+	created, err := svc.CreateHostedZoneWithContext(ctx, &route53.CreateHostedZoneInput{
+		Name:            aws.String(newName),
+		CallerReference: aws.String(fmt.Sprintf("r53q-rename-%d", time.Now().UnixNano())),
+		HostedZoneConfig: &route53.HostedZoneConfig{
+			Comment: aws.String(fmt.Sprintf("renamed from %s by r53q", strings.TrimSuffix(oldName, "."))),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", newName, err)
+	}
+	invalidateZoneCache()
+	newID := aws.StringValue(created.HostedZone.Id)
+	fmt.Printf("Created zone %s (%s)\n", strings.TrimSuffix(newName, "."), strings.TrimPrefix(newID, "/hostedzone/"))
+
+	// plain records first so same-zone alias targets exist before the
+	// aliases pointing at them
+	var plain, aliases []*route53.Change
+	for _, rr := range sets {
+		if isApexNSOrSOA(rr, oldName) {
+			continue
+		}
+		c := &route53.Change{
+			Action:            aws.String(route53.ChangeActionCreate),
+			ResourceRecordSet: rewriteRecordSet(rr, oldName, newName, aws.StringValue(old.Id), newID),
+		}
+		if rr.AliasTarget != nil {
+			aliases = append(aliases, c)
+		} else {
+			plain = append(plain, c)
+		}
+	}
+	ids, err := submitChanges(ctx, svc, newID, append(plain, aliases...), comment)
+	if err != nil {
+		return fmt.Errorf("copying records to %s (the new zone was left in place): %w", newName, err)
+	}
+	fmt.Printf("Copied %d record sets\n", len(plain)+len(aliases))
+
+	fmt.Println("Name servers (update your registrar):")
+	for _, ns := range created.DelegationSet.NameServers {
+		fmt.Println(aws.StringValue(ns))
+	}
+	if err := waitForChanges(ctx, svc, append([]string{changeID(created.ChangeInfo)}, ids...)...); err != nil {
+		return err
+	}
+
+	if !deleteOld {
+		return nil
+	}
+	if !assumeYes && !confirm(fmt.Sprintf("Delete old zone %s and all its records?", strings.TrimSuffix(oldName, "."))) {
+		fmt.Println("Old zone kept")
+		return nil
+	}
+	return purgeAndDeleteZone(ctx, svc, old, sets, comment)
+}
+
+// purgeAndDeleteZone deletes every record set except the apex NS/SOA, then
+// the zone itself. Aliases go first so nothing they point at disappears
+// underneath them.
+func purgeAndDeleteZone(ctx context.Context, svc Route53API, zone *route53.HostedZone, sets []*route53.ResourceRecordSet, comment string) error {
+	var aliases, plain []*route53.Change
+	for _, rr := range sets {
+		if isApexNSOrSOA(rr, aws.StringValue(zone.Name)) {
+			continue
+		}
+		c := &route53.Change{Action: aws.String(route53.ChangeActionDelete), ResourceRecordSet: rr}
+		if rr.AliasTarget != nil {
+			aliases = append(aliases, c)
+		} else {
+			plain = append(plain, c)
+		}
+	}
+	if _, err := submitChanges(ctx, svc, aws.StringValue(zone.Id), append(aliases, plain...), comment); err != nil {
+		return fmt.Errorf("emptying %s: %w", aws.StringValue(zone.Name), err)
+	}
+	out, err := svc.DeleteHostedZoneWithContext(ctx, &route53.DeleteHostedZoneInput{Id: zone.Id})
+	if err != nil {
+		return fmt.Errorf("deleting %s: %w", aws.StringValue(zone.Name), err)
+	}
+	invalidateZoneCache()
+	fmt.Printf("Deleted zone %s\n", strings.TrimSuffix(aws.StringValue(zone.Name), "."))
+	return waitForChanges(ctx, svc, changeID(out.ChangeInfo))
+}
+
+// rewriteRecordSet returns a copy of rr moved from the oldName zone to the
+// newName zone
+func rewriteRecordSet(rr *route53.ResourceRecordSet, oldName, newName, oldID, newID string) *route53.ResourceRecordSet {
+	cp := *rr
+	cp.Name = aws.String(rewriteSuffix(aws.StringValue(rr.Name), oldName, newName))
+	if hostnameTypes[aws.StringValue(rr.Type)] {
+		cp.ResourceRecords = make([]*route53.ResourceRecord, len(rr.ResourceRecords))
+		for i, r := range rr.ResourceRecords {
+			f := strings.Fields(aws.StringValue(r.Value))
+			if len(f) > 0 {
+				f[len(f)-1] = rewriteSuffix(f[len(f)-1], oldName, newName)
+			}
+			cp.ResourceRecords[i] = &route53.ResourceRecord{Value: aws.String(strings.Join(f, " "))}
+		}
+	}
+	if rr.AliasTarget != nil && sameZoneID(aws.StringValue(rr.AliasTarget.HostedZoneId), oldID) {
+		at := *rr.AliasTarget
+		at.HostedZoneId = aws.String(strings.TrimPrefix(newID, "/hostedzone/"))
+		at.DNSName = aws.String(rewriteSuffix(aws.StringValue(rr.AliasTarget.DNSName), oldName, newName))
+		cp.AliasTarget = &at
+	}
+	return &cp
+}
+
+// rewriteSuffix swaps the oldName suffix of name for newName, keeping
+// name's own trailing dot (or lack of one); names outside oldName are
+// returned unchanged
+func rewriteSuffix(name, oldName, newName string) string {
+	n := strings.TrimSuffix(name, ".")
+	o := strings.TrimSuffix(oldName, ".")
+	var out string
+	switch {
+	case strings.EqualFold(n, o):
+		out = strings.TrimSuffix(newName, ".")
+	case len(n) > len(o) && strings.EqualFold(n[len(n)-len(o)-1:], "."+o):
+		out = n[:len(n)-len(o)] + strings.TrimSuffix(newName, ".")
+	default:
+		return name
+	}
+	if strings.HasSuffix(name, ".") {
+		out += "."
+	}
+	return out
+}
+
+// equalNames compares DNS names case-insensitively, ignoring a trailing dot
+func equalNames(a, b string) bool {
+	return strings.EqualFold(strings.TrimSuffix(a, "."), strings.TrimSuffix(b, "."))
+}
+
+// sameZoneID compares zone IDs with or without the /hostedzone/ prefix
+func sameZoneID(a, b string) bool {
+	return strings.TrimPrefix(a, "/hostedzone/") == strings.TrimPrefix(b, "/hostedzone/")
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+// renameMock adds zone creation, zone deletion and health check deletion to
+// mockRoute53; the new zone is Z9
+type renameMock struct {
+	*mockRoute53
+	deleted []string
+	checks  []string // deleted health checks
+}
+
+func (m *renameMock) CreateHostedZoneWithContext(_ aws.Context, in *route53.CreateHostedZoneInput, _ ...request.Option) (*route53.CreateHostedZoneOutput, error) {
+	return &route53.CreateHostedZoneOutput{
+		HostedZone:    &route53.HostedZone{Id: aws.String("/hostedzone/Z9"), Name: in.Name},
+		DelegationSet: &route53.DelegationSet{NameServers: aws.StringSlice([]string{"ns-1.awsdns-01.org"})},
+		ChangeInfo:    &route53.ChangeInfo{Id: aws.String("/change/C0")},
+	}, nil
+}
+
+func (m *renameMock) DeleteHostedZoneWithContext(_ aws.Context, in *route53.DeleteHostedZoneInput, _ ...request.Option) (*route53.DeleteHostedZoneOutput, error) {
+	m.deleted = append(m.deleted, aws.StringValue(in.Id))
+	return &route53.DeleteHostedZoneOutput{ChangeInfo: &route53.ChangeInfo{Id: aws.String("/change/D1")}}, nil
+}
+
+func (m *renameMock) DeleteHealthCheckWithContext(_ aws.Context, in *route53.DeleteHealthCheckInput, _ ...request.Option) (*route53.DeleteHealthCheckOutput, error) {
+	m.checks = append(m.checks, aws.StringValue(in.HealthCheckId))
+	return &route53.DeleteHealthCheckOutput{}, nil
+}
+
+func newRenameMock() *renameMock {
+	m := newMock()
+	local := aliasSet("alias.ear.pm.", "A", "www.ear.pm.")
+	local.AliasTarget.HostedZoneId = aws.String("Z1")
+	m.sets["/hostedzone/Z1"] = []*route53.ResourceRecordSet{
+		plainSet("ear.pm.", "NS", 172800, "ns-1.awsdns-01.org."),
+		plainSet("ear.pm.", "SOA", 900, "ns-1.awsdns-01.org. awsdns-hostmaster.amazon.com. 1 7200 900 1209600 86400"),
+		local,
+		aliasSet("cdn.ear.pm.", "A", "d1.cloudfront.net."),
+		plainSet("ear.pm.", "MX", 3600, "10 mx.ear.pm."),
+		plainSet("www.ear.pm.", "CNAME", 60, "ear.pm."),
+		plainSet("ext.ear.pm.", "CNAME", 60, "example.com."),
+	}
+	return &renameMock{mockRoute53: m}
+}
+
+// recordList renders record sets as "name type value", with alias targets
+// as "zone/dnsname"
+func recordList(changes []*route53.Change) []string {
+	out := make([]string, len(changes))
+	for i, c := range changes {
+		rr := c.ResourceRecordSet
+		v := ""
+		if rr.AliasTarget != nil {
+			v = aws.StringValue(rr.AliasTarget.HostedZoneId) + "/" + aws.StringValue(rr.AliasTarget.DNSName)
+		} else {
+			for _, r := range rr.ResourceRecords {
+				v += aws.StringValue(r.Value)
+			}
+		}
+		out[i] = aws.StringValue(c.Action) + " " + aws.StringValue(rr.Name) + " " + aws.StringValue(rr.Type) + " " + v
+	}
+	return out
+}
+
+func TestRenameZone(t *testing.T) {
+	setupTest(t)
+	m := newRenameMock()
+	stdout, err := captureStdout(t, func() error {
+		return renameZone(context.Background(), m, "ear.pm", "Ear.Example", "test", false, false)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.changes) != 1 || aws.StringValue(m.changes[0].HostedZoneId) != "/hostedzone/Z9" {
+		t.Fatalf("changes went to %v, want one batch to Z9", m.changes)
+	}
+	// apex NS/SOA stay behind, plain sets go before aliases, and only the
+	// same-zone alias is pointed at the new zone
+	want := []string{
+		"CREATE ear.example. MX 10 mx.ear.example.",
+		"CREATE www.ear.example. CNAME ear.example.",
+		"CREATE ext.ear.example. CNAME example.com.",
+		"CREATE alias.ear.example. A Z9/www.ear.example.",
+		"CREATE cdn.ear.example. A Z2FDTNDATAQYW2/d1.cloudfront.net.",
+	}
+	if got := recordList(m.changes[0].ChangeBatch.Changes); !slices.Equal(got, want) {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if aws.StringValue(m.changes[0].ChangeBatch.Comment) != "test" {
+		t.Errorf("comment %q, want %q", aws.StringValue(m.changes[0].ChangeBatch.Comment), "test")
+	}
+	if !strings.Contains(stdout, "Copied 5 record sets\n") || !strings.Contains(stdout, "ns-1.awsdns-01.org\n") {
+		t.Errorf("stdout:\n%s", stdout)
+	}
+	if len(m.deleted) != 0 {
+		t.Errorf("deleted %v without --delete-old", m.deleted)
+	}
+}
+
+func TestRenameZoneDeleteOld(t *testing.T) {
+	setupTest(t)
+	m := newRenameMock()
+	if _, err := captureStdout(t, func() error {
+		return renameZone(context.Background(), m, "ear.pm", "ear.example", "test", true, true)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.changes) != 2 || aws.StringValue(m.changes[1].HostedZoneId) != "/hostedzone/Z1" {
+		t.Fatalf("got %d batches, want a copy and a purge of Z1", len(m.changes))
+	}
+	want := []string{
+		"DELETE alias.ear.pm. A",
+		"DELETE cdn.ear.pm. A",
+		"DELETE ear.pm. MX",
+		"DELETE www.ear.pm. CNAME",
+		"DELETE ext.ear.pm. CNAME",
+	}
+	if got := changeList(m.changes[1].ChangeBatch.Changes); !slices.Equal(got, want) {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if !slices.Equal(m.deleted, []string{"/hostedzone/Z1"}) {
+		t.Errorf("deleted %v, want Z1", m.deleted)
+	}
+}
+
+func TestRenameZoneRefuses(t *testing.T) {
+	tests := []struct {
+		zone, domain, want string
+	}{
+		{"ear.pm", "EAR.PM.", "old and new domain are the same"},
+		{"example.org", "example.net", "private zones"},
+	}
+	for _, tt := range tests {
+		setupTest(t)
+		m := newRenameMock()
+		err := renameZone(context.Background(), m, tt.zone, tt.domain, "", false, true)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("rename %s to %s: err = %v, want %q", tt.zone, tt.domain, err, tt.want)
+		}
+		if len(m.changes) != 0 {
+			t.Errorf("rename %s to %s sent changes", tt.zone, tt.domain)
+		}
+	}
+}
+
+func TestRewriteSuffix(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{"ear.pm.", "ear.example."},
+		{"ear.pm", "ear.example"},
+		{"www.EAR.pm.", "www.ear.example."},
+		{"a.b.ear.pm", "a.b.ear.example"},
+		{"gear.pm.", "gear.pm."},
+		{"example.com.", "example.com."},
+	}
+	for _, tt := range tests {
+		if got := rewriteSuffix(tt.name, "ear.pm.", "ear.example."); got != tt.want {
+			t.Errorf("rewriteSuffix(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"golang.org/x/net/idna"
+)
+
+// domainProfile is IDNA lookup processing, relaxed to allow underscores
+// (_acme-challenge and the like); validateHostname checks the rest
+var domainProfile = idna.New(idna.MapForLookup(), idna.StrictDomainName(false), idna.BidiRule())
+
+// normalizeDomain returns name the way Route53 stores it: lower case,
+// internationalized labels in their ASCII (punycode) form, with a trailing
+// dot. Names that cannot be a domain are rejected.
+func normalizeDomain(name string) (string, error) {
+	ascii, err := domainProfile.ToASCII(strings.TrimSuffix(name, "."))
+	if err == nil {
+		err = validateHostname(ascii)
+	}
+	if err != nil {
+		return "", invalid(fmt.Errorf("invalid domain %q: %w", name, err))
+	}
+	return strings.ToLower(ascii) + ".", nil
+}
+
+// findZone resolves a zone ID (with or without the /hostedzone/ prefix) or a
+// domain name to its hosted zone. Reports whether identifier was a domain.
+// Domains are normalized first, so case and IDN spelling do not matter.
+// The zone list comes from the zone cache when fresh; zones found there
+// carry no record count.
+func findZone(ctx context.Context, svc Route53API, identifier string) (*route53.HostedZone, bool, error) {
+	dom := identifier
+	isDomain := strings.Contains(identifier, ".")
+	if isDomain {
+		var err error
+		if dom, err = normalizeDomain(identifier); err != nil {
+			return nil, isDomain, err
+		}
+	}
+	match := func(zones []*route53.HostedZone) *route53.HostedZone {
+		for _, z := range zones {
+			idVal := aws.StringValue(z.Id)
+			nameVal := aws.StringValue(z.Name)
+			if (isDomain && nameVal == dom) ||
+				(!isDomain && (idVal == identifier || idVal == "/hostedzone/"+identifier)) {
+				return z
+			}
+		}
+		return nil
+	}
+
+	if z := match(readZoneCache()); z != nil {
+		debugf(1, "resolved %s to %s (zone cache)", identifier, aws.StringValue(z.Id))
+		return z, isDomain, nil
+	}
+	// a miss may just mean the cache predates the zone, so ask the API
+	zones, err := allZones(ctx, svc)
+	if err != nil {
+		return nil, isDomain, err
+	}
+	writeZoneCache(zones)
+	if z := match(zones); z != nil {
+		debugf(1, "resolved %s to %s (%d zones listed)", identifier, aws.StringValue(z.Id), len(zones))
+		return z, isDomain, nil
+	}
+
+	if isDomain {
+		names := make([]string, len(zones))
+		for i, z := range zones {
+			names[i] = aws.StringValue(z.Name)
+		}
+		if s := suggestNames(dom, names); len(s) > 0 {
+			return nil, isDomain, notFound(fmt.Errorf("no zone %q; did you mean %s?",
+				strings.TrimSuffix(dom, "."), quoteJoin(s)))
+		}
+	}
+	return nil, isDomain, notFound(fmt.Errorf("no hosted zone found for %q", identifier))
+}
+
+// maxSuggestions caps how many "did you mean" candidates are offered
+const maxSuggestions = 3
+
+// suggestNames returns the names closest to name by edit distance, ignoring
+// anything too far off to be a plausible typo. Trailing dots are stripped.
+func suggestNames(name string, names []string) []string {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	// allow roughly one typo per four characters, but at least two
+	limit := len(name) / 4
+	if limit < 2 {
+		limit = 2
+	}
+
+	type candidate struct {
+		name string
+		dist int
+	}
+	var cands []candidate
+	for _, n := range names {
+		n = strings.TrimSuffix(n, ".")
+		if d := levenshtein(name, strings.ToLower(n)); d <= limit {
+			cands = append(cands, candidate{n, d})
+		}
+	}
+	sort.SliceStable(cands, func(i, j int) bool { return cands[i].dist < cands[j].dist })
+
+	var out []string
+	for i := 0; i < len(cands) && i < maxSuggestions; i++ {
+		out = append(out, cands[i].name)
+	}
+	return out
+}
+
+// levenshtein returns the edit distance between a and b
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+// quoteJoin renders names as 'a', 'b' or 'c'
+func quoteJoin(names []string) string {
+	q := make([]string, len(names))
+	for i, n := range names {
+		q[i] = "'" + n + "'"
+	}
+	if len(q) == 1 {
+		return q[0]
+	}
+	return strings.Join(q[:len(q)-1], ", ") + " or " + q[len(q)-1]
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestNormalizeDomain(t *testing.T) {
+	tests := []struct {
+		in, want string
+		wantErr  bool
+	}{
+		{"ear.pm", "ear.pm.", false},
+		{"Ear.PM.", "ear.pm.", false},
+		{"müller.de", "xn--mller-kva.de.", false},
+		{"Müller.DE", "xn--mller-kva.de.", false},
+		{"xn--mller-kva.de", "xn--mller-kva.de.", false},
+		{"_dmarc.ear.pm", "_dmarc.ear.pm.", false},
+		{"", "", true},
+		{"ear..pm", "", true},
+		{"-ear.pm", "", true},
+		{"ear pm.com", "", true},
+	}
+	for _, tt := range tests {
+		got, err := normalizeDomain(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("normalizeDomain(%q) = %q, want an error", tt.in, got)
+			} else if exitCode(err) != exitInvalid {
+				t.Errorf("normalizeDomain(%q): exit code %d, want %d", tt.in, exitCode(err), exitInvalid)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("normalizeDomain(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestSuggestNames(t *testing.T) {
+	zones := []string{"ear.pm.", "example.org.", "example.com.", "examples.org.", "earentir.dev."}
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"ear.pn", []string{"ear.pm"}},
+		{"exmaple.org", []string{"example.org"}},
+		{"Example.ORG.", []string{"example.org", "examples.org"}},
+		{"nothing-like-it.net", nil},
+		{"ear.pm", []string{"ear.pm"}},
+	}
+	for _, tt := range tests {
+		if got := suggestNames(tt.name, zones); !slices.Equal(got, tt.want) {
+			t.Errorf("suggestNames(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	} // at most maxSuggestions, closest first
+	got := suggestNames("abc.io", []string{"abd.io", "abcd.io", "xyz.io", "ab.io", "abc.com", "abc.io"})
+	if want := []string{"abc.io", "abd.io", "abcd.io"}; !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"müller", "muller", 1},
+	}
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// a domain lookup that misses suggests the closest zones
+func TestFindZoneSuggests(t *testing.T) {
+	setupTest(t)
+	_, _, err := findZone(context.Background(), newMock(), "exmple.org")
+	if err == nil || err.Error() != `no zone "exmple.org"; did you mean 'example.org'?` {
+		t.Errorf("err = %v", err)
+	}
+	if exitCode(err) != exitNotFound {
+		t.Errorf("exit code %d, want %d", exitCode(err), exitNotFound)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+// search --by fields
+const (
+	searchByName  = "name"
+	searchByValue = "value"
+)
+
+// searchMatches reports whether rr matches query: by name, when its name
+// contains query; by value, when a value or its alias target equals query.
+// Both ignore case, and value matching ignores trailing dots.
+func searchMatches(rr *route53.ResourceRecordSet, query, by string) bool {
+	if by == searchByName {
+		return strings.Contains(strings.ToLower(unescapeName(aws.StringValue(rr.Name))), strings.ToLower(query))
+	}
+	for _, r := range rr.ResourceRecords {
+		if equalNames(aws.StringValue(r.Value), query) {
+			return true
+		}
+	}
+	return rr.AliasTarget != nil && equalNames(aws.StringValue(rr.AliasTarget.DNSName), query)
+}
+
+// searchRecords prints the records of every zone that match query, walking
+// zones on at most workers goroutines
+func searchRecords(ctx context.Context, svc Route53API, query, by string, workers int) error {
+	if by != searchByName && by != searchByValue {
+		return invalid(fmt.Errorf("unknown search field %q (want name or value)", by))
+	}
+	if workers < 1 {
+		return invalid(errors.New("--concurrency must be at least 1"))
+	}
+	zones, err := allZones(ctx, svc)
+	if err != nil {
+		return err
+	}
+
+	type match struct {
+		Zone string `json:"zone"`
+		recordJSON
+	}
+	rows := make([][][]string, len(zones))
+	objs := make([][]any, len(zones))
+	errs := make([]error, len(zones))
+	parallelN(workers, len(zones), func(i int) {
+		sets, err := zoneRecordSets(ctx, svc, aws.StringValue(zones[i].Id))
+		if err != nil {
+			errs[i] = err
+			return
+		}
+		zoneName := aws.StringValue(zones[i].Name)
+		for _, rr := range sets {
+			if searchMatches(rr, query, by) {
+				rows[i] = append(rows[i], append([]string{zoneName}, recordRow(rr)...))
+				objs[i] = append(objs[i], match{zoneName, newRecordJSON(rr)})
+			}
+		}
+	})
+
+	w := newListWriter([]string{"Zone", "Name", "Type", "TTL", "Values"}, false)
+	for i := range zones {
+		if errs[i] != nil {
+			return fmt.Errorf("scanning %s: %w", aws.StringValue(zones[i].Name), errs[i])
+		}
+		if err := w.addPage(rows[i], objs[i]); err != nil {
+			return err
+		}
+	}
+	if err := w.close(); err != nil {
+		return err
+	}
+	if w.n == 0 && !quiet {
+		fmt.Fprintf(os.Stderr, "no records match %q\n", query)
+	}
+	return nil
+}
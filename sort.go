@@ -0,0 +1,59 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+// record sort keys accepted by list records --sort
+const (
+	sortName = "name"
+	sortType = "type"
+	sortTTL  = "ttl"
+)
+
+// validateSort rejects unknown --sort keys
+func validateSort(key string) error {
+	switch key {
+	case sortName, sortType, sortTTL:
+		return nil
+	}
+	return invalid(fmt.Errorf("unknown sort key %q (want name, type or ttl)", key))
+}
+
+// sortRecordSets orders sets by key, then by name and type so sets of the
+// same name stay grouped; reverse flips the whole order
+func sortRecordSets(sets []*route53.ResourceRecordSet, key string, reverse bool) {
+	byName := func(a, b *route53.ResourceRecordSet) int {
+		return cmp.Compare(strings.ToLower(unescapeName(aws.StringValue(a.Name))),
+			strings.ToLower(unescapeName(aws.StringValue(b.Name))))
+	}
+	byType := func(a, b *route53.ResourceRecordSet) int {
+		return cmp.Compare(aws.StringValue(a.Type), aws.StringValue(b.Type))
+	}
+	keys := []func(a, b *route53.ResourceRecordSet) int{byName, byType}
+	switch key {
+	case sortType:
+		keys = []func(a, b *route53.ResourceRecordSet) int{byType, byName}
+	case sortTTL:
+		keys = slices.Insert(keys, 0, func(a, b *route53.ResourceRecordSet) int {
+			return cmp.Compare(aws.Int64Value(a.TTL), aws.Int64Value(b.TTL))
+		})
+	}
+	slices.SortStableFunc(sets, func(a, b *route53.ResourceRecordSet) int {
+		for _, k := range keys {
+			if c := k(a, b); c != 0 {
+				if reverse {
+					return -c
+				}
+				return c
+			}
+		}
+		return 0
+	})
+}
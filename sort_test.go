@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+func TestSortRecordSets(t *testing.T) {
+	sets := func() []*route53.ResourceRecordSet {
+		return []*route53.ResourceRecordSet{
+			plainSet("www.ear.pm.", "A", 300, "1.1.1.1"),
+			plainSet("ear.pm.", "TXT", 60, `"x"`),
+			plainSet(`\052.ear.pm.`, "A", 60, "1.1.1.1"),
+			plainSet("Api.ear.pm.", "CNAME", 3600, "ear.pm."),
+			plainSet("ear.pm.", "A", 300, "1.1.1.1"),
+		}
+	}
+	tests := []struct {
+		key     string
+		reverse bool
+		want    []string
+	}{
+		{sortName, false, []string{`\052.ear.pm. A 60`, "Api.ear.pm. CNAME 3600", "ear.pm. A 300", "ear.pm. TXT 60", "www.ear.pm. A 300"}},
+		{sortName, true, []string{"www.ear.pm. A 300", "ear.pm. TXT 60", "ear.pm. A 300", "Api.ear.pm. CNAME 3600", `\052.ear.pm. A 60`}},
+		{sortType, false, []string{`\052.ear.pm. A 60`, "ear.pm. A 300", "www.ear.pm. A 300", "Api.ear.pm. CNAME 3600", "ear.pm. TXT 60"}},
+		{sortTTL, false, []string{`\052.ear.pm. A 60`, "ear.pm. TXT 60", "ear.pm. A 300", "www.ear.pm. A 300", "Api.ear.pm. CNAME 3600"}},
+		{sortTTL, true, []string{"Api.ear.pm. CNAME 3600", "www.ear.pm. A 300", "ear.pm. A 300", "ear.pm. TXT 60", `\052.ear.pm. A 60`}},
+	}
+	for _, tt := range tests {
+		s := sets()
+		sortRecordSets(s, tt.key, tt.reverse)
+		var got []string
+		for _, rr := range s {
+			got = append(got, fmt.Sprintf("%s %s %d", aws.StringValue(rr.Name), aws.StringValue(rr.Type), aws.Int64Value(rr.TTL)))
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("--sort %s reverse=%t:\ngot  %q\nwant %q", tt.key, tt.reverse, got, tt.want)
+		}
+	}
+}
+
+func TestValidateSort(t *testing.T) {
+	for _, key := range []string{sortName, sortType, sortTTL} {
+		if err := validateSort(key); err != nil {
+			t.Errorf("validateSort(%q) = %v", key, err)
+		}
+	}
+	if err := validateSort("size"); err == nil || exitCode(err) != exitInvalid {
+		t.Errorf("validateSort(size) = %v, want an invalid-input error", err)
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+// maxTXTString is the longest character-string a TXT record can hold
+const maxTXTString = 255
+
+// validateValues checks values against what record type typ accepts, so
+// obvious typos fail before a round trip to Route53. TXT and SPF values
+// longer than 255 bytes come back split into quoted strings; unquoted TXT
+// values are quoted.
+func validateValues(typ string, values []string) ([]string, error) {
+	out := make([]string, len(values))
+	for i, v := range values {
+		var err error
+		out[i] = v
+		switch typ {
+		case route53.RRTypeA:
+			if a, perr := netip.ParseAddr(v); perr != nil || !a.Is4() {
+				err = errors.New("not an IPv4 address")
+			}
+		case route53.RRTypeAaaa:
+			if a, perr := netip.ParseAddr(v); perr != nil || !a.Is6() || a.Is4In6() {
+				err = errors.New("not an IPv6 address")
+			}
+		case route53.RRTypeCname, route53.RRTypeNs, route53.RRTypePtr:
+			err = validateHostname(v)
+		case route53.RRTypeMx:
+			err = validateFields(v, "preference", 1)
+		case route53.RRTypeSrv:
+			err = validateFields(v, "priority weight port", 3)
+		case route53.RRTypeTxt, route53.RRTypeSpf:
+			out[i], err = chunkTXT(v)
+		}
+		if err != nil {
+			return nil, invalid(fmt.Errorf("invalid %s value %q: %w", typ, v, err))
+		}
+	}
+	return out, nil
+}
+
+// validateHostname checks name is a syntactically valid DNS name: labels of
+// 1-63 letters, digits, hyphens or underscores, 253 bytes in all
+func validateHostname(name string) error {
+	n := strings.TrimSuffix(name, ".")
+	if n == "" {
+		return errors.New("empty hostname")
+	}
+	if len(n) > 253 {
+		return errors.New("hostname longer than 253 bytes")
+	}
+	for _, l := range strings.Split(n, ".") {
+		if l == "" || len(l) > 63 {
+			return fmt.Errorf("label %q must be 1-63 bytes", l)
+		}
+		if l[0] == '-' || l[len(l)-1] == '-' {
+			return fmt.Errorf("label %q starts or ends with a hyphen", l)
+		}
+		for _, c := range l {
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+				return fmt.Errorf("label %q contains %q", l, c)
+			}
+		}
+	}
+	return nil
+}
+
+// validateFields checks v is n numeric fields (named by names, 0-65535
+// each) followed by a hostname, as in MX and SRV values. A lone "." as the
+// hostname is the null MX of RFC 7505 or SRV's "no service" (RFC 2782).
+func validateFields(v, names string, n int) error {
+	f := strings.Fields(v)
+	if len(f) != n+1 {
+		return fmt.Errorf("want %q followed by a hostname", names)
+	}
+	for i, name := range strings.Fields(names) {
+		if _, err := strconv.ParseUint(f[i], 10, 16); err != nil {
+			return fmt.Errorf("%s %q is not a number from 0 to 65535", name, f[i])
+		}
+	}
+	if f[n] == "." {
+		return nil
+	}
+	return validateHostname(f[n])
+}
+
+// chunkTXT returns v as quoted character-strings of at most 255 bytes each.
+// A value that starts with a quote is parsed as one or more quoted strings
+// and kept as-is unless one is too long; anything else is taken as literal
+// text.
+func chunkTXT(v string) (string, error) {
+	var texts []string
+	if strings.HasPrefix(v, `"`) {
+		var err error
+		if texts, err = parseQuoted(v); err != nil {
+			return "", err
+		}
+		if !slices.ContainsFunc(texts, func(t string) bool { return len(t) > maxTXTString }) {
+			return v, nil
+		}
+	} else {
+		texts = []string{v}
+	}
+
+	var chunks []string
+	for _, t := range texts {
+		for len(t) > maxTXTString {
+			chunks = append(chunks, t[:maxTXTString])
+			t = t[maxTXTString:]
+		}
+		chunks = append(chunks, t)
+	}
+	for i, c := range chunks {
+		c = strings.ReplaceAll(c, `\`, `\\`)
+		chunks[i] = `"` + strings.ReplaceAll(c, `"`, `\"`) + `"`
+	}
+	return strings.Join(chunks, " "), nil
+}
+
+// parseQuoted splits `"a" "b\"c"` into its unescaped strings
+func parseQuoted(v string) ([]string, error) {
+	var out []string
+	for v = strings.TrimSpace(v); v != ""; v = strings.TrimSpace(v) {
+		if v[0] != '"' {
+			return nil, fmt.Errorf("text outside quotes at %q", v)
+		}
+		var b strings.Builder
+		i := 1
+		for ; i < len(v) && v[i] != '"'; i++ {
+			if v[i] == '\\' && i+1 < len(v) {
+				i++
+			}
+			b.WriteByte(v[i])
+		}
+		if i == len(v) {
+			return nil, errors.New("unterminated quote")
+		}
+		out = append(out, b.String())
+		v = v[i+1:]
+	}
+	return out, nil
+}
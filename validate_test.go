@@ -0,0 +1,99 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateValues(t *testing.T) {
+	tests := []struct {
+		typ     string
+		value   string
+		want    string // "" means unchanged
+		wantErr string
+	}{
+		{"A", "192.0.2.1", "", ""},
+		{"A", "2001:db8::1", "", "not an IPv4 address"},
+		{"A", "192.0.2", "", "not an IPv4 address"},
+		{"AAAA", "2001:db8::1", "", ""},
+		{"AAAA", "::ffff:192.0.2.1", "", "not an IPv6 address"},
+		{"AAAA", "192.0.2.1", "", "not an IPv6 address"},
+		{"CNAME", "www.ear.pm.", "", ""},
+		{"CNAME", "_acme-challenge.ear.pm", "", ""},
+		{"CNAME", "-bad.ear.pm", "", "starts or ends with a hyphen"},
+		{"CNAME", "a..ear.pm", "", "must be 1-63 bytes"},
+		{"CNAME", strings.Repeat("a", 64) + ".ear.pm", "", "must be 1-63 bytes"},
+		{"CNAME", strings.Repeat("a.", 127) + "ab", "", "longer than 253 bytes"},
+		{"CNAME", "caf\u00e9.ear.pm", "", "contains"},
+		{"CNAME", ".", "", "empty hostname"},
+		{"NS", "ns-1.awsdns-01.org.", "", ""},
+		{"PTR", "host.ear.pm.", "", ""},
+		{"MX", "10 mx.ear.pm.", "", ""},
+		{"MX", "0 .", "", ""}, // null MX, RFC 7505
+		{"MX", "mx.ear.pm.", "", `want "preference" followed by a hostname`},
+		{"MX", "70000 mx.ear.pm.", "", "not a number from 0 to 65535"},
+		{"SRV", "0 5 5060 sip.ear.pm.", "", ""},
+		{"SRV", "0 0 0 .", "", ""}, // no service, RFC 2782
+		{"SRV", "0 5 sip.ear.pm.", "", `want "priority weight port" followed by a hostname`},
+		{"SRV", "0 5 x sip.ear.pm.", "", `port "x" is not a number`},
+		{"TXT", "hello world", `"hello world"`, ""},
+		{"TXT", `"already" "quoted"`, "", ""},
+		{"TXT", `"open`, "", "unterminated quote"},
+		{"SPF", "v=spf1 -all", `"v=spf1 -all"`, ""},
+		{"CAA", `0 issue "amazon.com"`, "", ""},
+	}
+	for _, tt := range tests {
+		got, err := validateValues(tt.typ, []string{tt.value})
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s %q: err = %v, want %q", tt.typ, tt.value, err, tt.wantErr)
+			} else if exitCode(err) != exitInvalid {
+				t.Errorf("%s %q: exit code %d, want %d", tt.typ, tt.value, exitCode(err), exitInvalid)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s %q: %v", tt.typ, tt.value, err)
+			continue
+		}
+		want := tt.want
+		if want == "" {
+			want = tt.value
+		}
+		if got[0] != want {
+			t.Errorf("%s %q = %q, want %q", tt.typ, tt.value, got[0], want)
+		}
+	}
+}
+
+func TestChunkTXT(t *testing.T) {
+	a255, a256 := strings.Repeat("a", 255), strings.Repeat("a", 256)
+	tests := []struct {
+		in, want, wantErr string
+	}{
+		{"", `""`, ""},
+		{"plain text", `"plain text"`, ""},
+		{`say "hi" \o/`, `"say \"hi\" \\o/"`, ""},
+		{a255, `"` + a255 + `"`, ""},
+		{a256, `"` + a255 + `" "a"`, ""},
+		{strings.Repeat("a", 510), `"` + a255 + `" "` + a255 + `"`, ""},
+		// quoted strings are kept as written while they fit
+		{`"` + a255 + `"`, `"` + a255 + `"`, ""},
+		{`"x"  "y\"z"`, `"x"  "y\"z"`, ""},
+		{`"` + a256 + `" "b"`, `"` + a255 + `" "a" "b"`, ""},
+		{`"a" b`, "", `text outside quotes at "b"`},
+		{`"a\"`, "", "unterminated quote"},
+	}
+	for _, tt := range tests {
+		got, err := chunkTXT(tt.in)
+		if tt.wantErr != "" {
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("chunkTXT(%.20q): err = %v, want %q", tt.in, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("chunkTXT(%.20q) = %.40q, %v; want %.40q", tt.in, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// verbose is how many times -V was given: 1 logs each API call with its
+// timing plus zone resolution, 2 adds the SDK's own request/response dumps
+var verbose int
+
+// debugf logs to stderr when verbosity is at least level, keeping stdout
+// clean for pipes
+func debugf(level int, format string, args ...any) {
+	if verbose >= level {
+		fmt.Fprintf(os.Stderr, "debug: "+format+"\n", args...)
+	}
+}
+
+// verboseConfig returns the SDK logging settings for -VV and up
+func verboseConfig() *aws.Config {
+	if verbose < 2 {
+		return nil
+	}
+	return &aws.Config{
+		LogLevel: aws.LogLevel(aws.LogDebugWithHTTPBody | aws.LogDebugWithRequestRetries | aws.LogDebugWithRequestErrors),
+		Logger: aws.LoggerFunc(func(args ...any) {
+			fmt.Fprintln(os.Stderr, append([]any{"debug:"}, args...)...)
+		}),
+	}
+}
+
+// logRequests registers a handler that logs every completed API call: its
+// operation, HTTP status, retries and duration. Each page of a paginated
+// listing is its own call.
+func logRequests(h *request.Handlers) {
+	if verbose < 1 {
+		return
+	}
+	h.Complete.PushBack(func(r *request.Request) {
+		status := 0
+		if r.HTTPResponse != nil {
+			status = r.HTTPResponse.StatusCode
+		}
+		msg := fmt.Sprintf("%s %s: HTTP %d in %s", r.ClientInfo.ServiceName, r.Operation.Name, status,
+			time.Since(r.Time).Round(time.Millisecond))
+		if r.RetryCount > 0 {
+			msg += fmt.Sprintf(", %d retries", r.RetryCount)
+		}
+		if r.Error != nil {
+			msg += ", error: " + r.Error.Error()
+		}
+		debugf(1, "%s", msg)
+	})
+}
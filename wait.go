@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+var (
+	// wait makes mutating commands block until their changes are INSYNC,
+	// for at most waitTimeout
+	wait        bool
+	waitTimeout time.Duration
+)
+
+// waitInterval is how often GetChange is polled under --wait
+const waitInterval = 5 * time.Second
+
+// waitForChanges polls each change until Route53 reports it INSYNC, printing
+// a dot per poll to stderr. It does nothing without --wait and skips dry-run
+// placeholders. Gives up after --wait-timeout, naming the pending change.
+func waitForChanges(ctx context.Context, svc Route53API, ids ...string) error {
+	if !wait {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, waitTimeout)
+	defer cancel()
+	for _, id := range ids {
+		if id == "" || id == dryRunID {
+			continue
+		}
+		short := strings.TrimPrefix(id, "/change/")
+		if !quiet {
+			fmt.Fprintf(os.Stderr, "waiting for %s to be INSYNC ", short)
+		}
+		for {
+			out, err := svc.GetChangeWithContext(ctx, &route53.GetChangeInput{Id: aws.String(id)})
+			if err == nil && aws.StringValue(out.ChangeInfo.Status) == route53.ChangeStatusInsync {
+				break
+			}
+			if err == nil {
+				if !quiet {
+					fmt.Fprint(os.Stderr, ".")
+				}
+				select {
+				case <-time.After(waitInterval):
+					continue
+				case <-ctx.Done():
+					err = ctx.Err()
+				}
+			}
+			if !quiet {
+				fmt.Fprintln(os.Stderr)
+			}
+			if errors.Is(err, context.DeadlineExceeded) {
+				return fmt.Errorf("change %s still PENDING after %s; check it later with its ID", short, waitTimeout)
+			}
+			return fmt.Errorf("waiting for change %s: %w", short, err)
+		}
+		if !quiet {
+			fmt.Fprintln(os.Stderr, "done")
+		}
+	}
+	return nil
+}
+
+// changeID returns the ID in info, or "" when there is none
+func changeID(info *route53.ChangeInfo) string {
+	if info == nil {
+		return ""
+	}
+	return aws.StringValue(info.Id)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+// allZones fetches every hosted zone in the account
+func allZones(ctx context.Context, svc Route53API) ([]*route53.HostedZone, error) {
+	var zones []*route53.HostedZone
+	err := svc.ListHostedZonesPagesWithContext(ctx, &route53.ListHostedZonesInput{MaxItems: maxItems(0)},
+		func(out *route53.ListHostedZonesOutput, last bool) bool {
+			zones = append(zones, out.HostedZones...)
+			return !last
+		})
+	return zones, err
+}
+
+// pointsAt reports whether rr answers with target: a value equal to it, a
+// value whose last field is it (CNAME/MX/SRV targets) or an alias to it.
+// Comparison ignores case and trailing dots.
+func pointsAt(rr *route53.ResourceRecordSet, target string) bool {
+	for _, r := range rr.ResourceRecords {
+		v := aws.StringValue(r.Value)
+		if equalNames(v, target) {
+			return true
+		}
+		if f := strings.Fields(v); len(f) > 1 && equalNames(f[len(f)-1], target) {
+			return true
+		}
+	}
+	return rr.AliasTarget != nil && equalNames(aws.StringValue(rr.AliasTarget.DNSName), target)
+}
+
+// where prints every record, across all zones, that points at target.
+// Zones are scanned concurrently.
+func where(ctx context.Context, svc Route53API, target string) error {
+	zones, err := allZones(ctx, svc)
+	if err != nil {
+		return err
+	}
+
+	type match struct {
+		Zone string `json:"zone"`
+		Name string `json:"name"`
+		Type string `json:"type"`
+	}
+	matches := make([][]match, len(zones))
+	errs := make([]error, len(zones))
+	parallel(len(zones), func(i int) {
+		sets, err := zoneRecordSets(ctx, svc, aws.StringValue(zones[i].Id))
+		if err != nil {
+			errs[i] = err
+			return
+		}
+		for _, rr := range sets {
+			if pointsAt(rr, target) {
+				matches[i] = append(matches[i], match{
+					aws.StringValue(zones[i].Name),
+					aws.StringValue(rr.Name),
+					aws.StringValue(rr.Type),
+				})
+			}
+		}
+	})
+
+	var rows [][]string
+	var objs []any
+	for i, ms := range matches {
+		if errs[i] != nil {
+			return fmt.Errorf("scanning %s: %w", aws.StringValue(zones[i].Name), errs[i])
+		}
+		for _, m := range ms {
+			rows = append(rows, []string{m.Zone, m.Name, m.Type})
+			objs = append(objs, m)
+		}
+	}
+	w := newListWriter([]string{"Zone", "Name", "Type"}, false)
+	if err := w.addPage(rows, objs); err != nil {
+		return err
+	}
+	if err := w.close(); err != nil {
+		return err
+	}
+	if len(rows) == 0 && !quiet {
+		fmt.Fprintf(os.Stderr, "nothing points at %s\n", target)
+	}
+	return nil
+}
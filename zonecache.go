@@ -0,0 +1,135 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+// zoneCachePattern matches the zone cache files inside the cache dir, one
+// per account and endpoint: zones-<key>.json
+const zoneCachePattern = "zones-*.json"
+
+var (
+	// cacheTTL is how long the zone mapping is trusted
+	cacheTTL = 5 * time.Minute
+	// noCache bypasses the zone cache for reads (it is still refreshed)
+	noCache bool
+	// zoneCacheKey identifies the credentials and endpoint the cached zones
+	// belong to; "" (no client built yet) disables the cache
+	zoneCacheKey string
+)
+
+// setZoneCacheKey derives zoneCacheKey from the role being assumed, else the
+// access key ID of the session's credentials, plus the Route53 endpoint, so
+// switching accounts never serves another account's zone IDs. Temporary
+// credentials get a fresh key, and so a fresh cache, per session.
+//
+// The account ID would be the natural key, but resolving it takes an
+// sts:GetCallerIdentity call on every run, which costs about what the cache
+// saves and needs a permission r53q otherwise never asks for. An access key
+// belongs to exactly one account, so keying by it never mixes accounts; two
+// keys for one account merely keep separate caches.
+func setZoneCacheKey(sess *session.Session) {
+	zoneCacheKey = ""
+	who := assumeRoleARN
+	if who == "" {
+		creds, err := sess.Config.Credentials.Get()
+		if err != nil {
+			return
+		}
+		who = creds.AccessKeyID
+	}
+	sum := sha256.Sum256([]byte(who + "\n" + configuredEndpoint()))
+	zoneCacheKey = hex.EncodeToString(sum[:8])
+}
+
+// zoneCachePath returns the zone cache file for zoneCacheKey
+func zoneCachePath(dir string) string {
+	return filepath.Join(dir, "zones-"+zoneCacheKey+".json")
+}
+
+// zoneCache is the on-disk form of the zone mapping
+type zoneCache struct {
+	Fetched time.Time    `json:"fetched"`
+	Zones   []cachedZone `json:"zones"`
+}
+
+type cachedZone struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Private bool   `json:"private"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// readZoneCache returns the cached zones, carrying ID, name and config but
+// no record count, or nil when the cache is off, missing, unreadable or
+// older than cacheTTL
+func readZoneCache() []*route53.HostedZone {
+	if noCache || cacheTTL <= 0 || zoneCacheKey == "" {
+		return nil
+	}
+	dir, err := cacheDirPath()
+	if err != nil {
+		return nil
+	}
+	data, err := os.ReadFile(zoneCachePath(dir))
+	if err != nil {
+		return nil
+	}
+	var c zoneCache
+	if json.Unmarshal(data, &c) != nil || time.Since(c.Fetched) > cacheTTL {
+		return nil
+	}
+	zones := make([]*route53.HostedZone, len(c.Zones))
+	for i, z := range c.Zones {
+		zones[i] = &route53.HostedZone{
+			Id:     aws.String(z.ID),
+			Name:   aws.String(z.Name),
+			Config: &route53.HostedZoneConfig{PrivateZone: aws.Bool(z.Private)},
+		}
+		if z.Comment != "" {
+			zones[i].Config.Comment = aws.String(z.Comment)
+		}
+	}
+	return zones
+}
+
+// writeZoneCache stores the mapping for zones; failures are ignored since
+// the cache is only an optimisation
+func writeZoneCache(zones []*route53.HostedZone) {
+	if zoneCacheKey == "" {
+		return
+	}
+	dir, err := cacheDir()
+	if err != nil {
+		return
+	}
+	c := zoneCache{Fetched: time.Now(), Zones: make([]cachedZone, len(zones))}
+	for i, z := range zones {
+		c.Zones[i] = cachedZone{ID: aws.StringValue(z.Id), Name: aws.StringValue(z.Name)}
+		if z.Config != nil {
+			c.Zones[i].Private = aws.BoolValue(z.Config.PrivateZone)
+			c.Zones[i].Comment = aws.StringValue(z.Config.Comment)
+		}
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return
+	}
+	os.WriteFile(zoneCachePath(dir), data, 0600)
+}
+
+// invalidateZoneCache drops the mapping after zones are created or deleted
+func invalidateZoneCache() {
+	if dir, err := cacheDirPath(); err == nil && zoneCacheKey != "" {
+		os.Remove(zoneCachePath(dir))
+	}
+}
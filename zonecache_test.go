@@ -0,0 +1,69 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+// switching profiles must switch cache files, so one account's zone IDs are
+// never served to another
+func TestZoneCacheProfileSwitch(t *testing.T) {
+	setupTest(t)
+	clearEnv(t, "AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_PROFILE",
+		"AWS_ENDPOINT_URL", "AWS_ENDPOINT_URL_ROUTE_53")
+	dir := t.TempDir()
+	credsFile := filepath.Join(dir, "credentials")
+	creds := "[a]\naws_access_key_id = AKIAAAAA\naws_secret_access_key = s\n" +
+		"[b]\naws_access_key_id = AKIABBBB\naws_secret_access_key = s\n"
+	if err := os.WriteFile(credsFile, []byte(creds), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", credsFile)
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	savedDir, savedRole, savedEndpoint := cacheDirFlag, assumeRoleARN, endpointURL
+	t.Cleanup(func() { cacheDirFlag, assumeRoleARN, endpointURL = savedDir, savedRole, savedEndpoint })
+	cacheDirFlag, assumeRoleARN, endpointURL = filepath.Join(dir, "cache"), "", ""
+	noCache = false
+
+	use := func(profile string) string {
+		t.Helper()
+		sess, err := newSession(&config{Profile: profile})
+		if err != nil {
+			t.Fatal(err)
+		}
+		setZoneCacheKey(sess)
+		if zoneCacheKey == "" {
+			t.Fatalf("profile %s: no cache key", profile)
+		}
+		return zoneCacheKey
+	}
+
+	keyA := use("a")
+	writeZoneCache([]*route53.HostedZone{{Id: aws.String("/hostedzone/ZA"), Name: aws.String("ear.pm.")}})
+	if zones := readZoneCache(); len(zones) != 1 || aws.StringValue(zones[0].Id) != "/hostedzone/ZA" {
+		t.Fatalf("profile a reads %v, want its own zone", zones)
+	}
+
+	keyB := use("b")
+	if keyA == keyB {
+		t.Fatalf("profiles a and b share cache key %s", keyA)
+	}
+	if zones := readZoneCache(); zones != nil {
+		t.Errorf("profile b reads profile a's zones: %v", zones)
+	}
+	files, _ := filepath.Glob(filepath.Join(cacheDirFlag, zoneCachePattern))
+	if len(files) != 1 || filepath.Base(files[0]) != "zones-"+keyA+".json" {
+		t.Errorf("cache files %v, want only profile a's", files)
+	}
+
+	if use("a") != keyA {
+		t.Error("profile a's key is not stable")
+	}
+	if zones := readZoneCache(); len(zones) != 1 {
+		t.Errorf("back on profile a, read %v", zones)
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+// createZone creates a public hosted zone for domain and prints its ID and
+// then the name servers to delegate to, one per line, or all of it as one
+// JSON object
+func createZone(ctx context.Context, svc Route53API, domain string) error {
+	name, err := normalizeDomain(domain)
+	if err != nil {
+		return err
+	}
+	out, err := svc.CreateHostedZoneWithContext(ctx, &route53.CreateHostedZoneInput{
+		Name:            aws.String(name),
+		CallerReference: aws.String(fmt.Sprintf("r53q-create-%d", time.Now().UnixNano())),
+	})
+	if err != nil {
+		return err
+	}
+	invalidateZoneCache()
+	created := delegationJSON{
+		ID:          strings.TrimPrefix(aws.StringValue(out.HostedZone.Id), "/hostedzone/"),
+		Name:        aws.StringValue(out.HostedZone.Name),
+		NameServers: []string{},
+	}
+	if out.DelegationSet != nil {
+		created.NameServers = aws.StringValueSlice(out.DelegationSet.NameServers)
+	}
+	if outputFormat == outputJSON {
+		if err := printJSON(created); err != nil {
+			return err
+		}
+	} else {
+		fmt.Println(created.ID)
+		for _, ns := range created.NameServers {
+			fmt.Println(ns)
+		}
+	}
+	return waitForChanges(ctx, svc, changeID(out.ChangeInfo))
+}
+
+// deleteZone deletes a zone (by ID or domain). A zone still holding record
+// sets besides its apex NS/SOA is refused unless force, which deletes those
+// first. With cascade, health checks its record sets reference are deleted
+// afterwards too, except those a record set in another zone still uses.
+// Asks for confirmation unless assumeYes.
+func deleteZone(ctx context.Context, svc Route53API, identifier, comment string, force, cascade, assumeYes bool) error {
+	zone, _, err := findZone(ctx, svc, identifier)
+	if err != nil {
+		return err
+	}
+	sets, err := zoneRecordSets(ctx, svc, aws.StringValue(zone.Id))
+	if err != nil {
+		return err
+	}
+	var extra int
+	for _, rr := range sets {
+		if !isApexNSOrSOA(rr, aws.StringValue(zone.Name)) {
+			extra++
+		}
+	}
+
+	name := strings.TrimSuffix(aws.StringValue(zone.Name), ".")
+	question := fmt.Sprintf("Delete zone %s", name)
+	if extra > 0 {
+		if !force {
+			return fmt.Errorf("%s still has %d record sets besides NS/SOA; delete them first or use --force", name, extra)
+		}
+		question += fmt.Sprintf(" and its %d record sets", extra)
+	}
+	var checks []string
+	if cascade {
+		var shared map[string]string
+		checks, shared, err = unsharedHealthChecks(ctx, svc, aws.StringValue(zone.Id), zoneHealthChecks(sets))
+		if err != nil {
+			return err
+		}
+		if !quiet {
+			for _, id := range slices.Sorted(maps.Keys(shared)) {
+				fmt.Fprintf(os.Stderr, "keeping health check %s, still used in %s\n", id, strings.TrimSuffix(shared[id], "."))
+			}
+		}
+		if len(checks) > 0 {
+			question += fmt.Sprintf(" and %d health checks", len(checks))
+		}
+	}
+	if !assumeYes && !confirm(question+"?") {
+		return errors.New("aborted")
+	}
+	if err := purgeAndDeleteZone(ctx, svc, zone, sets, comment); err != nil {
+		return err
+	}
+	for _, id := range checks {
+		if _, err := svc.DeleteHealthCheckWithContext(ctx, &route53.DeleteHealthCheckInput{HealthCheckId: aws.String(id)}); err != nil {
+			return fmt.Errorf("deleting health check %s (zone %s is already gone): %w", id, name, err)
+		}
+		fmt.Printf("Deleted health check %s\n", id)
+	}
+	return nil
+}
+
+// zoneHealthChecks returns the distinct health check IDs sets reference,
+// sorted
+func zoneHealthChecks(sets []*route53.ResourceRecordSet) []string {
+	var ids []string
+	for _, rr := range sets {
+		if id := aws.StringValue(rr.HealthCheckId); id != "" && !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// unsharedHealthChecks splits ids into those no record set outside zoneID
+// references and the rest, mapped to a zone still using them. Every other
+// zone is scanned, concurrently; any failure aborts, since a zone that was
+// not scanned might be using a check.
+func unsharedHealthChecks(ctx context.Context, svc Route53API, zoneID string, ids []string) ([]string, map[string]string, error) {
+	if len(ids) == 0 {
+		return nil, nil, nil
+	}
+	zones, err := allZones(ctx, svc)
+	if err != nil {
+		return nil, nil, err
+	}
+	used := make([]map[string]bool, len(zones))
+	errs := make([]error, len(zones))
+	parallel(len(zones), func(i int) {
+		if sameZoneID(aws.StringValue(zones[i].Id), zoneID) {
+			return
+		}
+		sets, err := zoneRecordSets(ctx, svc, aws.StringValue(zones[i].Id))
+		if err != nil {
+			errs[i] = err
+			return
+		}
+		used[i] = map[string]bool{}
+		for _, id := range zoneHealthChecks(sets) {
+			used[i][id] = true
+		}
+	})
+	shared := map[string]string{}
+	for i, z := range zones {
+		if errs[i] != nil {
+			return nil, nil, fmt.Errorf("scanning %s for health check use: %w", aws.StringValue(z.Name), errs[i])
+		}
+		for _, id := range ids {
+			if used[i][id] && shared[id] == "" {
+				shared[id] = aws.StringValue(z.Name)
+			}
+		}
+	}
+	var own []string
+	for _, id := range ids {
+		if shared[id] == "" {
+			own = append(own, id)
+		}
+	}
+	return own, shared, nil
+}
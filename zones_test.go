@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+func TestDeleteZoneCascadeHealthChecks(t *testing.T) {
+	checked := func(name, setID, check string) *route53.ResourceRecordSet {
+		rr := plainSet(name, "A", 60, "10.0.0.1")
+		rr.SetIdentifier, rr.Failover, rr.HealthCheckId = aws.String(setID), aws.String("PRIMARY"), aws.String(check)
+		return rr
+	}
+	tests := []struct {
+		name    string
+		cascade bool
+		checks  []string
+		kept    string
+	}{
+		{"without cascade", false, nil, ""},
+		// hc2 is also used in example.org, so only hc1 goes
+		{"with cascade", true, []string{"hc1"}, "keeping health check hc2, still used in example.org"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTest(t)
+			m := newRenameMock()
+			m.sets["/hostedzone/Z1"] = []*route53.ResourceRecordSet{
+				plainSet("ear.pm.", "NS", 172800, "ns-1.awsdns-01.org."),
+				checked("api.ear.pm.", "a", "hc1"),
+				checked("www.ear.pm.", "a", "hc2"),
+				checked("web.ear.pm.", "a", "hc1"),
+			}
+			m.sets["/hostedzone/Z2"] = []*route53.ResourceRecordSet{checked("api.example.org.", "a", "hc2")}
+			var stdout string
+			stderr, err := capture(t, &os.Stderr, func() (err error) {
+				stdout, err = captureStdout(t, func() error {
+					return deleteZone(context.Background(), m, "ear.pm", "test", true, tt.cascade, true)
+				})
+				return err
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(m.deleted, []string{"/hostedzone/Z1"}) {
+				t.Errorf("deleted zones %v, want Z1", m.deleted)
+			}
+			if !slices.Equal(m.checks, tt.checks) {
+				t.Errorf("deleted health checks %v, want %v", m.checks, tt.checks)
+			}
+			if !strings.Contains(stderr, tt.kept) || (tt.kept == "") != (stderr == "") {
+				t.Errorf("stderr %q, want %q", stderr, tt.kept)
+			}
+			for _, id := range tt.checks {
+				if !strings.Contains(stdout, "Deleted health check "+id+"\n") {
+					t.Errorf("stdout lacks the deletion of %s:\n%s", id, stdout)
+				}
+			}
+		})
+	}
+}
+
+func TestZoneHealthChecks(t *testing.T) {
+	a := plainSet("a.ear.pm.", "A", 60, "10.0.0.1")
+	a.HealthCheckId = aws.String("hc2")
+	b := plainSet("b.ear.pm.", "A", 60, "10.0.0.2")
+	b.HealthCheckId = aws.String("hc1")
+	c := plainSet("c.ear.pm.", "A", 60, "10.0.0.3")
+	c.HealthCheckId = aws.String("hc2")
+	got := zoneHealthChecks([]*route53.ResourceRecordSet{a, b, c, plainSet("d.ear.pm.", "A", 60, "10.0.0.4")})
+	if want := []string{"hc1", "hc2"}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}